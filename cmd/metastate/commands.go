@@ -0,0 +1,241 @@
+package main
+
+// ----- ---- --- -- -
+// Copyright 2019, 2020 The Axiom Foundation. All Rights Reserved.
+//
+// Licensed under the Apache License 2.0 (the "License").  You may not use
+// this file except in compliance with the License.  You can obtain a copy
+// in the file LICENSE in the source distribution or at
+// https://www.apache.org/licenses/LICENSE-2.0.txt
+// - -- --- ---- -----
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"text/tabwriter"
+
+	metast "github.com/ndau/metanode/pkg/meta/state"
+	"github.com/ndau/noms/go/datas"
+	"github.com/pkg/errors"
+)
+
+func noArgs(args []string) error {
+	if len(args) > 0 {
+		return fmt.Errorf("unexpected arguments: %q", args)
+	}
+	return nil
+}
+
+func newTabWriter(w io.Writer) *tabwriter.Writer {
+	return tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+}
+
+// show prints the current metastate
+func show(w io.Writer, db datas.Database, ds datas.Dataset, args []string) error {
+	if err := noArgs(args); err != nil {
+		return err
+	}
+	var child metast.RawState
+	metastate, err := metast.MetastateAtHeight(db, ds, &child, 0)
+	if err != nil {
+		return err
+	}
+
+	tw := newTabWriter(w)
+	fmt.Fprintf(tw, "commit:\t%s\n", ds.HeadRef().TargetHash())
+	fmt.Fprintf(tw, "height:\t%d\n", metastate.Height)
+	fmt.Fprintf(tw, "schema version:\t%d\n", metastate.GetSchemaVersion())
+	fmt.Fprintf(tw, "child state:\t%s\n", child.Value.Hash())
+	if metastate.IsTrackingEvidence() {
+		fmt.Fprintf(tw, "evidence:\t%d records\n", len(metastate.GetEvidence()))
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	validators, err := metastate.GetValidators()
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "\nvalidators (%d):\n", len(validators))
+	tw = newTabWriter(w)
+	fmt.Fprintln(tw, "  address\tpower")
+	for _, v := range validators {
+		fmt.Fprintf(tw, "  %s\t%d\n", base64.StdEncoding.EncodeToString(v.Address), v.Power)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	showVoteStats(w, metastate)
+	if metastate.IsTrackingVoteTotals() {
+		showVoteTotals(w, metastate.GetVoteTotals())
+	}
+	return nil
+}
+
+// showVoteStats summarizes the rounds in the vote stats window by validator
+func showVoteStats(w io.Writer, metastate metast.Metastate) {
+	history := metastate.Stats.History
+	fmt.Fprintf(w, "\nvote stats (%d rounds", len(history))
+	if len(history) > 0 {
+		fmt.Fprintf(w, ": heights %d-%d", history[0].Height, history[len(history)-1].Height)
+	}
+	fmt.Fprintln(w, "):")
+
+	totals := make(map[string]metast.VoteTotals)
+	for _, round := range history {
+		for addr, nrs := range round.Validators {
+			vt := totals[addr]
+			vt.Add(round.Height, nrs)
+			totals[addr] = vt
+		}
+	}
+	printVoteTotals(w, totals)
+}
+
+func showVoteTotals(w io.Writer, totals map[string]metast.VoteTotals) {
+	fmt.Fprintf(w, "\nvote totals (%d validators):\n", len(totals))
+	printVoteTotals(w, totals)
+}
+
+func printVoteTotals(w io.Writer, totals map[string]metast.VoteTotals) {
+	addrs := make([]string, 0, len(totals))
+	for addr := range totals {
+		addrs = append(addrs, addr)
+	}
+	sort.Strings(addrs)
+
+	tw := newTabWriter(w)
+	fmt.Fprintln(tw, "  address\trounds\tvoted\tagainst consensus\tfirst height\tlast height")
+	for _, addr := range addrs {
+		vt := totals[addr]
+		fmt.Fprintf(
+			tw, "  %s\t%d\t%d\t%d\t%d\t%d\n",
+			addr, vt.Rounds, vt.Voted, vt.AgainstConsensus, vt.FirstHeight, vt.LastHeight,
+		)
+	}
+	tw.Flush()
+}
+
+// history lists the metastate of every commit, newest first
+func history(w io.Writer, db datas.Database, ds datas.Dataset, args []string) error {
+	if err := noArgs(args); err != nil {
+		return err
+	}
+	tw := newTabWriter(w)
+	fmt.Fprintln(tw, "height\tvalidators\tvote rounds\tchild state")
+	var child metast.RawState
+	err := metast.IterMetastates(db, ds, &child, func(metastate metast.Metastate) error {
+		_, err := fmt.Fprintf(
+			tw, "%d\t%d\t%d\t%s\n",
+			metastate.Height, len(metastate.Validators), len(metastate.Stats.History), child.Value.Hash(),
+		)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	return tw.Flush()
+}
+
+// dump prints the child state as of a height as JSON
+func dump(w io.Writer, db datas.Database, ds datas.Dataset, args []string) error {
+	var height uint64
+	switch len(args) {
+	case 0:
+	case 1:
+		var err error
+		height, err = strconv.ParseUint(args[0], 10, 64)
+		if err != nil {
+			return errors.Wrap(err, "parsing height")
+		}
+	default:
+		return noArgs(args[1:])
+	}
+
+	var child metast.RawState
+	_, err := metast.MetastateAtHeight(db, ds, &child, height)
+	if err != nil {
+		return err
+	}
+	j, err := jsonify(child.Value)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(j)
+}
+
+// verify checks that history can be iterated
+func verify(w io.Writer, db datas.Database, ds datas.Dataset, args []string) error {
+	if err := noArgs(args); err != nil {
+		return err
+	}
+	commits, err := metast.VerifyHistory(db, ds)
+	if err != nil {
+		return errors.Wrapf(err, "after %d good commits", commits-1)
+	}
+	fmt.Fprintf(w, "ok: %d commits, none with more than one parent\n", commits)
+	return nil
+}
+
+// parseCheckArgs parses the arguments of check, returning whether to rewind
+func parseCheckArgs(args []string) (rewind bool, err error) {
+	flags := flag.NewFlagSet("check", flag.ContinueOnError)
+	flags.BoolVar(&rewind, "rewind", false, "if problems are found, set the dataset head to the last good commit")
+	if err = flags.Parse(args); err != nil {
+		return false, err
+	}
+	return rewind, noArgs(flags.Args())
+}
+
+// checkWrites is true when check may rewind the dataset
+func checkWrites(args []string) bool {
+	rewind, err := parseCheckArgs(args)
+	return err == nil && rewind
+}
+
+// check checks the integrity of every commit, optionally rewinding the
+// dataset to the last good commit
+func check(w io.Writer, db datas.Database, ds datas.Dataset, args []string) error {
+	rewind, err := parseCheckArgs(args)
+	if err != nil {
+		return err
+	}
+
+	// the child state's type is unknown, so its contents aren't checked
+	report, err := metast.CheckIntegrity(db, ds, &metast.RawState{})
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "checked %d commits\n", report.Commits)
+	if report.OK() {
+		fmt.Fprintln(w, "ok: no problems found")
+		return nil
+	}
+	for _, problem := range report.Problems {
+		fmt.Fprintln(w, problem)
+	}
+	if report.LastGood == nil {
+		fmt.Fprintln(w, "no commit is known to be good")
+	} else {
+		fmt.Fprintf(w, "last good commit: %s (height %d)\n", report.LastGood.TargetHash(), report.LastGoodHeight)
+	}
+
+	if !rewind {
+		return fmt.Errorf("%d problems found", len(report.Problems))
+	}
+	_, err = metast.RewindToLastGood(db, ds, report)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(w, "rewound the dataset head to the last good commit")
+	return nil
+}
@@ -0,0 +1,55 @@
+package main
+
+// ----- ---- --- -- -
+// Copyright 2019, 2020 The Axiom Foundation. All Rights Reserved.
+//
+// Licensed under the Apache License 2.0 (the "License").  You may not use
+// this file except in compliance with the License.  You can obtain a copy
+// in the file LICENSE in the source distribution or at
+// https://www.apache.org/licenses/LICENSE-2.0.txt
+// - -- --- ---- -----
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	meta "github.com/ndau/metanode/pkg/meta/app"
+	nt "github.com/ndau/noms/go/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOnlyRewindWrites(t *testing.T) {
+	for _, cmd := range commands {
+		if cmd.name != "check" {
+			require.Nil(t, cmd.writes, cmd.name)
+		}
+	}
+	require.False(t, checkWrites(nil))
+	require.True(t, checkWrites([]string{"-rewind"}))
+	require.False(t, checkWrites([]string{"-rewind", "extra"}))
+}
+
+func TestOpenReadOnly(t *testing.T) {
+	dir, err := ioutil.TempDir("", "metastate")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	// write a commit to the dataset
+	db, err := meta.StorageConfig{Backend: meta.NBSBackend, Path: dir}.Open()
+	require.NoError(t, err)
+	_, err = db.CommitValue(db.GetDataset("ndau"), nt.String("head"))
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+
+	db, ds, err := open(dir, "ndau", true)
+	require.NoError(t, err)
+	defer db.Close()
+	_, err = db.SetHead(ds, ds.HeadRef())
+	require.Equal(t, meta.ErrReadOnly, err)
+
+	_, _, err = open(dir, "missing", true)
+	require.Error(t, err)
+	_, _, err = open("mem", "ndau", true)
+	require.Error(t, err)
+}
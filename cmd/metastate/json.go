@@ -0,0 +1,96 @@
+package main
+
+// ----- ---- --- -- -
+// Copyright 2019, 2020 The Axiom Foundation. All Rights Reserved.
+//
+// Licensed under the Apache License 2.0 (the "License").  You may not use
+// this file except in compliance with the License.  You can obtain a copy
+// in the file LICENSE in the source distribution or at
+// https://www.apache.org/licenses/LICENSE-2.0.txt
+// - -- --- ---- -----
+
+import (
+	"fmt"
+	"io/ioutil"
+
+	nt "github.com/ndau/noms/go/types"
+)
+
+// jsonify converts a noms value into a value which encoding/json can marshal
+//
+// Structs become objects and lists and sets become arrays. Maps become
+// objects if all of their keys are strings, and arrays of [key, value] pairs
+// otherwise. Blobs become byte slices, which encoding/json encodes as base64,
+// and refs become objects holding the hash of their target.
+func jsonify(v nt.Value) (out interface{}, err error) {
+	switch value := v.(type) {
+	case nt.Bool:
+		return bool(value), nil
+	case nt.Number:
+		return float64(value), nil
+	case nt.String:
+		return string(value), nil
+	case nt.Blob:
+		return ioutil.ReadAll(value.Reader())
+	case nt.Ref:
+		return map[string]string{"ref": value.TargetHash().String()}, nil
+	case nt.List:
+		items := make([]interface{}, 0, value.Len())
+		value.Iter(func(item nt.Value, _ uint64) (stop bool) {
+			var j interface{}
+			j, err = jsonify(item)
+			items = append(items, j)
+			return err != nil
+		})
+		return items, err
+	case nt.Set:
+		items := make([]interface{}, 0, value.Len())
+		value.Iter(func(item nt.Value) (stop bool) {
+			var j interface{}
+			j, err = jsonify(item)
+			items = append(items, j)
+			return err != nil
+		})
+		return items, err
+	case nt.Map:
+		return jsonifyMap(value)
+	case nt.Struct:
+		fields := make(map[string]interface{})
+		value.IterFields(func(name string, field nt.Value) (stop bool) {
+			fields[name], err = jsonify(field)
+			return err != nil
+		})
+		return fields, err
+	default:
+		return nil, fmt.Errorf("can't convert %T to json", v)
+	}
+}
+
+func jsonifyMap(m nt.Map) (out interface{}, err error) {
+	stringKeys := true
+	m.Iter(func(key, _ nt.Value) (stop bool) {
+		_, stringKeys = key.(nt.String)
+		return !stringKeys
+	})
+
+	if stringKeys {
+		object := make(map[string]interface{})
+		m.Iter(func(key, value nt.Value) (stop bool) {
+			object[string(key.(nt.String))], err = jsonify(value)
+			return err != nil
+		})
+		return object, err
+	}
+
+	pairs := make([][2]interface{}, 0, m.Len())
+	m.Iter(func(key, value nt.Value) (stop bool) {
+		var pair [2]interface{}
+		pair[0], err = jsonify(key)
+		if err == nil {
+			pair[1], err = jsonify(value)
+		}
+		pairs = append(pairs, pair)
+		return err != nil
+	})
+	return pairs, err
+}
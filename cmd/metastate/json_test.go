@@ -0,0 +1,51 @@
+package main
+
+// ----- ---- --- -- -
+// Copyright 2019, 2020 The Axiom Foundation. All Rights Reserved.
+//
+// Licensed under the Apache License 2.0 (the "License").  You may not use
+// this file except in compliance with the License.  You can obtain a copy
+// in the file LICENSE in the source distribution or at
+// https://www.apache.org/licenses/LICENSE-2.0.txt
+// - -- --- ---- -----
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/ndau/noms/go/spec"
+	nt "github.com/ndau/noms/go/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJsonify(t *testing.T) {
+	sp, err := spec.ForDatabase("mem")
+	require.NoError(t, err)
+	db := sp.GetDatabase()
+	defer db.Close()
+
+	value := nt.NewStruct("State", nt.StructData{
+		"Flag":   nt.Bool(true),
+		"Count":  nt.Number(3),
+		"Blob":   nt.NewBlob(db, bytes.NewReader([]byte{1, 2, 3})),
+		"List":   nt.NewList(db, nt.String("a"), nt.String("b")),
+		"Set":    nt.NewSet(db, nt.Number(1)),
+		"Names":  nt.NewMap(db, nt.String("x"), nt.Number(1)),
+		"Powers": nt.NewMap(db, nt.Number(1), nt.String("one")),
+	})
+
+	j, err := jsonify(value)
+	require.NoError(t, err)
+	out, err := json.Marshal(j)
+	require.NoError(t, err)
+	require.JSONEq(t, `{
+		"Flag": true,
+		"Count": 3,
+		"Blob": "AQID",
+		"List": ["a", "b"],
+		"Set": [1],
+		"Names": {"x": 1},
+		"Powers": [[1, "one"]]
+	}`, string(out))
+}
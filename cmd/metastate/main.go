@@ -0,0 +1,128 @@
+// ----- ---- --- -- -
+// Copyright 2019, 2020 The Axiom Foundation. All Rights Reserved.
+//
+// Licensed under the Apache License 2.0 (the "License").  You may not use
+// this file except in compliance with the License.  You can obtain a copy
+// in the file LICENSE in the source distribution or at
+// https://www.apache.org/licenses/LICENSE-2.0.txt
+// - -- --- ---- -----
+
+// Command metastate inspects a metanode's noms database offline.
+//
+// Usage:
+//
+//	metastate -db SPEC [-dataset NAME] COMMAND [ARGS]
+//
+// SPEC is the path of an nbs database, optionally prefixed by "nbs:", or the
+// URL of a noms server. The database is opened read-only, except by check
+// -rewind, but the node which owns it should be stopped: noms databases are
+// not safe for concurrent use.
+//
+// Commands:
+//
+//	show           print the current metastate: height, validators, vote stats
+//	history        list the metastate of every commit, newest first
+//	dump [HEIGHT]  print the child state as of HEIGHT as JSON; 0 means now
+//	verify         check the single-parent invariant on which history depends
+//	check          check the integrity of every commit
+//
+// check reports commits which are missing or don't match their hash, have
+// more than one parent, can't be unmarshaled, or whose height is below their
+// parent's. With -rewind, it then sets the dataset head to the newest commit
+// which, with all of its ancestors, has no problems. Nothing is deleted, but
+// the rewound commits are no longer part of the app's history; tendermint
+// will replay the corresponding blocks when the node restarts.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	meta "github.com/ndau/metanode/pkg/meta/app"
+	"github.com/ndau/noms/go/datas"
+)
+
+// a command runs against an open dataset
+type command struct {
+	name  string
+	usage string
+	run   func(w io.Writer, db datas.Database, ds datas.Dataset, args []string) error
+	// writes reports whether the command, with these args, modifies the
+	// database. If nil, it never does, and the database is opened read-only.
+	writes func(args []string) bool
+}
+
+var commands = []command{
+	{"show", "print the current metastate: height, validators, vote stats", show, nil},
+	{"history", "list the metastate of every commit, newest first", history, nil},
+	{"dump", "[HEIGHT] print the child state as of HEIGHT as JSON; 0 means now", dump, nil},
+	{"verify", "check the single-parent invariant on which history depends", verify, nil},
+	{"check", "[-rewind] check the integrity of every commit", check, checkWrites},
+}
+
+func usage() {
+	out := flag.CommandLine.Output()
+	fmt.Fprintf(out, "usage: %s -db SPEC [-dataset NAME] COMMAND [ARGS]\n\n", os.Args[0])
+	flag.PrintDefaults()
+	fmt.Fprintln(out, "\ncommands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(out, "  %-8s %s\n", cmd.name, cmd.usage)
+	}
+}
+
+func main() {
+	dbSpec := flag.String("db", "", "noms database spec: an nbs path or a noms server URL")
+	dataset := flag.String("dataset", "ndau", "name of the app's dataset")
+	flag.Usage = usage
+	flag.Parse()
+
+	if *dbSpec == "" || flag.NArg() == 0 {
+		usage()
+		os.Exit(2)
+	}
+	var cmd *command
+	for i := range commands {
+		if commands[i].name == flag.Arg(0) {
+			cmd = &commands[i]
+		}
+	}
+	if cmd == nil {
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n", flag.Arg(0))
+		usage()
+		os.Exit(2)
+	}
+
+	args := flag.Args()[1:]
+	readOnly := cmd.writes == nil || !cmd.writes(args)
+	db, ds, err := open(*dbSpec, *dataset, readOnly)
+	if err == nil {
+		err = cmd.run(os.Stdout, db, ds, args)
+		db.Close()
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %s\n", cmd.name, err)
+		os.Exit(1)
+	}
+}
+
+// open connects to the database and checks that the dataset has a head
+func open(dbSpec, dataset string, readOnly bool) (db datas.Database, ds datas.Dataset, err error) {
+	config, err := meta.ParseStorageSpec(dbSpec)
+	if err != nil {
+		return nil, ds, err
+	}
+	config.Dataset = dataset
+	config.ReadOnly = readOnly
+	db, err = config.Open()
+	if err != nil {
+		return nil, ds, err
+	}
+	ds = db.GetDataset(dataset)
+	if _, hasHead := ds.MaybeHeadRef(); !hasHead {
+		db.Close()
+		return nil, ds, fmt.Errorf("dataset %q is empty", dataset)
+	}
+	return db, ds, nil
+}
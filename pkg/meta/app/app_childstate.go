@@ -10,7 +10,13 @@ package app
 // - -- --- ---- -----
 
 
-import "github.com/pkg/errors"
+import (
+	"fmt"
+	"reflect"
+
+	metast "github.com/ndau/metanode/pkg/meta/state"
+	"github.com/pkg/errors"
+)
 
 func (a *App) invalidChildStateError() error {
 	return errors.Wrap(a.childStateValidity, "application state currently invalid, try again later")
@@ -23,3 +29,32 @@ func (a *App) invalidChildStateError() error {
 func (a *App) SetStateValidity(err error) {
 	a.childStateValidity = err
 }
+
+// cloneChildState returns an independent copy of the given child state
+//
+// If the state implements metast.Cloner, its Clone method is used. Otherwise,
+// the copy is made by a noms round trip, so it shares no memory with the
+// original.
+func (a *App) cloneChildState(state metast.State) (metast.State, error) {
+	if cloner, ok := state.(metast.Cloner); ok {
+		clone := cloner.Clone()
+		if clone == nil {
+			return nil, errors.New("cloning child state: Clone returned nil")
+		}
+		if reflect.TypeOf(clone) != reflect.TypeOf(state) {
+			return nil, fmt.Errorf("cloning child state: Clone returned %T, expected %T", clone, state)
+		}
+		return clone, nil
+	}
+
+	value, err := state.MarshalNoms(a.db)
+	if err != nil {
+		return nil, errors.Wrap(err, "cloning child state: marshaling")
+	}
+	clone := a.newChildState()
+	err = clone.UnmarshalNoms(value)
+	if err != nil {
+		return nil, errors.Wrap(err, "cloning child state: unmarshaling")
+	}
+	return clone, nil
+}
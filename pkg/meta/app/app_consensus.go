@@ -34,15 +34,46 @@ type IncrementalIndexer interface {
 
 // InitChain performs necessary chain initialization.
 //
-// This includes saving the initial validator set in the local state.
+// This includes saving the initial validator set in the local state, and
+// importing the genesis app state if a genesis decoder has been set.
+// Read-only apps log ErrReadOnly and leave their state unchanged.
 func (app *App) InitChain(req abci.RequestInitChain) (response abci.ResponseInitChain) {
 	logger := app.logRequestBare("InitChain", nil)
 
+	if app.readOnly {
+		// a read-only app already has a chain, and can't start another
+		logger.WithError(ErrReadOnly).Error("InitChain refused")
+		return
+	}
+
+	if len(req.AppStateBytes) > 0 {
+		if app.genesisDecoder == nil {
+			logger.Warn("genesis app state ignored: no genesis decoder set")
+		} else {
+			err := app.importGenesis(req.AppStateBytes)
+			if err != nil {
+				logger.WithError(err).Error("InitChain genesis import failed")
+				// fail fast if we can't actually initialize the chain
+				panic(err.Error())
+			}
+		}
+	}
+
 	// now add the initial validators set
 	for _, v := range req.Validators {
 		app.state.UpdateValidator(app.db, v)
 	}
 
+	// child apps may override the genesis consensus params by scheduling
+	// params for the initial height before InitChain
+	consensusParams := req.ConsensusParams
+	if app.state.IsTrackingConsensusParams() {
+		app.state.SetConsensusParams(metast.ConsensusParamsFrom(req.ConsensusParams))
+		if cp := app.state.ApplyScheduledConsensusParams(app.height); cp != nil {
+			consensusParams = cp.ABCI()
+		}
+	}
+
 	// commiting here ensures two things:
 	// 1. we actually have a head value
 	// 2. the initial validators are present from tendermint height 0
@@ -63,18 +94,20 @@ func (app *App) InitChain(req abci.RequestInitChain) (response abci.ResponseInit
 	}
 
 	app.ValUpdates = make([]abci.ValidatorUpdate, 0)
+	app.genesisAppHash = app.Hash()
+	logger.WithField("app.hash", app.HashStr()).Info("genesis app hash")
 
-	// Note - Tendermint 0.33 upgrade: Should the app_hash be returned to tendermint to
-	// allow starting the block sync? Not with this tendermint version
-	// return
+	// ResponseInitChain has no AppHash field until tendermint 0.34, so the
+	// genesis app hash can't be returned; see SetGenesisDecoder
 	return abci.ResponseInitChain{
-		ConsensusParams: req.ConsensusParams,
+		ConsensusParams: consensusParams,
 		Validators:      req.Validators,
 	}
-	// End Note
 }
 
 // BeginBlock tracks the block hash and header information
+//
+// Read-only apps log ErrReadOnly and leave their state unchanged.
 func (app *App) BeginBlock(req abci.RequestBeginBlock) abci.ResponseBeginBlock {
 	tmHeight := req.GetHeader().Height
 	tmTime := req.GetHeader().Time
@@ -98,6 +131,14 @@ func (app *App) BeginBlock(req abci.RequestBeginBlock) abci.ResponseBeginBlock {
 		}
 	}()
 
+	if app.readOnly {
+		// the round stats, height, migrations, and evidence of a block all
+		// belong to the state, which a read-only app can't commit
+		err = ErrReadOnly
+		logger = logger.WithField("err.context", "beginning a block on a read-only app")
+		return abci.ResponseBeginBlock{}
+	}
+
 	app.blockTime, err = math.TimestampFrom(tmTime)
 	if err != nil {
 		// we panic because without a good block time, we can't recover
@@ -114,6 +155,9 @@ func (app *App) BeginBlock(req abci.RequestBeginBlock) abci.ResponseBeginBlock {
 	height := uint64(tmHeight)
 	app.SetHeight(height)
 
+	app.runMigrations(logger)
+	app.handleEvidence(logger, req)
+
 	// Tell the search we have a new block on the way.
 	search := app.GetSearch()
 	if search != nil {
@@ -132,7 +176,7 @@ func (app *App) DeliverTx(request abci.RequestDeliverTx) (response abci.Response
 	var err error
 	var logger log.FieldLogger
 
-	tx, response.Code, logger, err = app.validateTransactable(request.Tx)
+	tx, response.Code, logger, err = app.validateTransactable(request.Tx, app.Height())
 
 	logger = app.requestLogger("DeliverTx", true, logger)
 
@@ -155,26 +199,15 @@ func (app *App) DeliverTx(request abci.RequestDeliverTx) (response abci.Response
 		response.Log = err.Error()
 		return
 	}
-	app.checkChild()
-	err = tx.Apply(app.childApp)
+	if app.readOnly {
+		err = ErrReadOnly
+		logger = logger.WithField("err.context", "delivering to a read-only app")
+		response.Code = uint32(code.InvalidNodeState)
+		response.Log = err.Error()
+		return
+	}
+	err = app.applyTxChecked(tx, logger)
 	if err == nil {
-		// wrap the deferred thunks in a format that app.UpdateState can call
-		wthunks := make([]func(metast.State) (metast.State, error), 0, len(app.deferredThunks))
-		for _, thunk := range app.deferredThunks {
-			wthunks = append(wthunks, func(st metast.State) (metast.State, error) {
-				st = thunk(st)
-				if st == nil {
-					// thunks are never allowed to return nil states,
-					// and if one does so, we can't recover
-					panic("deferred thunk returned nil state")
-				}
-				return st, nil
-			})
-		}
-		// ignore the returned error: if no thunk errors (and they aren't allowed to!),
-		// then the UpdateState call can't error
-		app.UpdateState(wthunks...)
-
 		// the qty of pending txs informs whether we noms-commit, or just continue
 		app.transactionsPending++
 
@@ -196,15 +229,55 @@ func (app *App) DeliverTx(request abci.RequestDeliverTx) (response abci.Response
 	return
 }
 
-// EndBlock updates the validator set
+// applyTx applies a tx and its deferred thunks to the child state
+func (app *App) applyTx(tx metatx.Transactable) error {
+	app.checkChild()
+	err := tx.Apply(app.childApp)
+	if err != nil {
+		return err
+	}
+	// wrap the deferred thunks in a format that app.UpdateState can call
+	wthunks := make([]func(metast.State) (metast.State, error), 0, len(app.deferredThunks))
+	for _, thunk := range app.deferredThunks {
+		wthunks = append(wthunks, func(st metast.State) (metast.State, error) {
+			st = thunk(st)
+			if st == nil {
+				// thunks are never allowed to return nil states,
+				// and if one does so, we can't recover
+				panic("deferred thunk returned nil state")
+			}
+			return st, nil
+		})
+	}
+	// ignore the returned error: if no thunk errors (and they aren't allowed to!),
+	// then the UpdateState call can't error
+	app.UpdateState(wthunks...)
+	return nil
+}
+
+// EndBlock updates the validator set and any scheduled consensus params
+//
+// Read-only apps log ErrReadOnly and return no updates.
 func (app *App) EndBlock(req abci.RequestEndBlock) abci.ResponseEndBlock {
-	app.logRequest("EndBlock", nil)
-	return abci.ResponseEndBlock{ValidatorUpdates: app.ValUpdates}
+	logger := app.logRequest("EndBlock", nil)
+	if app.readOnly {
+		logger.WithError(ErrReadOnly).Error("EndBlock refused")
+		return abci.ResponseEndBlock{}
+	}
+	response := abci.ResponseEndBlock{ValidatorUpdates: app.ValUpdates}
+	if cp := app.state.ApplyScheduledConsensusParams(app.Height()); cp != nil {
+		response.ConsensusParamUpdates = cp.ABCI()
+		// the new params must be persisted even if this block has no transactions
+		app.transactionsPending++
+		logger.WithField("consensusParams", *cp).Info("updating consensus params")
+	}
+	return response
 }
 
 // Commit saves a new version
 //
-// Panics if InitChain has not been called.
+// Panics if InitChain has not been called. Read-only apps log ErrReadOnly
+// and return their unchanged app hash.
 func (app *App) Commit() abci.ResponseCommit {
 	var err error
 	var logger log.FieldLogger
@@ -227,7 +300,16 @@ func (app *App) Commit() abci.ResponseCommit {
 	defer finalizeLogger()
 
 	logger = logger.WithField("abci.sequence", "mid")
-	if app.transactionsPending > 0 {
+	if app.readOnly {
+		// DeliverTx rejected every tx, so no work is lost: unlike a failed
+		// commit, this doesn't warrant a panic
+		app.transactionsPending = 0
+		err = ErrReadOnly
+		logger = logger.WithFields(log.Fields{
+			"err.context":   "committing a read-only app",
+			"commit.status": "refused",
+		})
+	} else if app.transactionsPending > 0 {
 		app.transactionsPending = 0
 		err = app.commit(logger)
 		if err != nil {
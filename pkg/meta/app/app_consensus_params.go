@@ -0,0 +1,65 @@
+package app
+
+// ----- ---- --- -- -
+// Copyright 2019, 2020 The Axiom Foundation. All Rights Reserved.
+//
+// Licensed under the Apache License 2.0 (the "License").  You may not use
+// this file except in compliance with the License.  You can obtain a copy
+// in the file LICENSE in the source distribution or at
+// https://www.apache.org/licenses/LICENSE-2.0.txt
+// - -- --- ---- -----
+
+import (
+	"github.com/ndau/metanode/pkg/meta/app/code"
+	metast "github.com/ndau/metanode/pkg/meta/state"
+	"github.com/pkg/errors"
+	abci "github.com/tendermint/tendermint/abci/types"
+)
+
+// ConsensusParamsEndpoint is the query endpoint for the current consensus params
+//
+// The response value is the protobuf encoding of abci.ConsensusParams.
+const ConsensusParamsEndpoint = "/consensus/params"
+
+// TrackConsensusParams begins persisting the consensus params in the metastate.
+//
+// See metast.Metastate.TrackConsensusParams for when this should be called.
+func (app *App) TrackConsensusParams() {
+	app.state.TrackConsensusParams()
+}
+
+// GetConsensusParams returns the current consensus params
+//
+// The bool is false if consensus params are not tracked.
+func (app *App) GetConsensusParams() (metast.ConsensusParams, bool) {
+	return app.state.GetConsensusParams(), app.state.IsTrackingConsensusParams()
+}
+
+// ScheduleConsensusParams schedules a change to the consensus params.
+//
+// The new params are returned to tendermint from the EndBlock of the given
+// height. Child apps will typically call this while applying a governance
+// transaction.
+func (app *App) ScheduleConsensusParams(height uint64, cp metast.ConsensusParams) error {
+	return app.state.ScheduleConsensusParams(height, cp)
+}
+
+func consensusParamsQuery(appI interface{}, request abci.RequestQuery, response *abci.ResponseQuery) {
+	app, ok := appI.(interface {
+		GetConsensusParams() (metast.ConsensusParams, bool)
+		QueryError(error, *abci.ResponseQuery, string)
+	})
+	if !ok {
+		response.Code = uint32(code.QueryError)
+		response.Log = "child app does not embed *meta.App"
+		return
+	}
+	cp, tracked := app.GetConsensusParams()
+	if !tracked {
+		app.QueryError(errors.New("consensus params are not tracked"), response, "")
+		return
+	}
+	var err error
+	response.Value, err = cp.ABCI().Marshal()
+	app.QueryError(err, response, "marshaling consensus params")
+}
@@ -0,0 +1,248 @@
+package app
+
+// ----- ---- --- -- -
+// Copyright 2019, 2020 The Axiom Foundation. All Rights Reserved.
+//
+// Licensed under the Apache License 2.0 (the "License").  You may not use
+// this file except in compliance with the License.  You can obtain a copy
+// in the file LICENSE in the source distribution or at
+// https://www.apache.org/licenses/LICENSE-2.0.txt
+// - -- --- ---- -----
+
+import (
+	"bytes"
+	"math/rand"
+	"reflect"
+
+	metast "github.com/ndau/metanode/pkg/meta/state"
+	metatx "github.com/ndau/metanode/pkg/meta/transaction"
+	"github.com/ndau/noms/go/diff"
+	nt "github.com/ndau/noms/go/types"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	abci "github.com/tendermint/tendermint/abci/types"
+)
+
+// DeterminismCheck configures a debug mode of DeliverTx which detects
+// transactables whose Apply is nondeterministic
+//
+// Before each tx is applied, it is applied to independent copies of the
+// state: one copy per trial. The state resulting from each trial must be
+// identical to that resulting from the real application. A transactable
+// which reads the clock or uses randomness will fail this check.
+//
+// Go randomizes the iteration order of maps, so each trial may iterate over
+// maps in a different order from the others. For small maps, the runtime
+// varies little more than the starting point, so ShuffleMaps also rebuilds
+// the maps of each trial's child state in a random insertion order. More
+// trials make it more likely that a dependence on map order is detected.
+//
+// The check is expensive, and each tx is applied Trials+1 times: the trials'
+// side effects outside the metastate, such as on a search index, are not
+// undone. It must never be enabled on a production node, so it is only
+// available in builds with the determinism build tag:
+//
+//	go test -tags determinism ./...
+type DeterminismCheck struct {
+	// Trials is the number of independent trial applications of each tx.
+	// 0 means 1.
+	Trials int
+	// ShuffleMaps rebuilds every map reachable from each trial's child state,
+	// as unmarshaled from noms, with its entries inserted in a random order.
+	// Each trial uses a different, fixed seed, so that violations reproduce.
+	ShuffleMaps bool
+	// Hook, if set, is called for each violation. Violations are always
+	// logged.
+	Hook DeterminismHook
+}
+
+// A DeterminismViolation describes a tx whose application was nondeterministic
+type DeterminismViolation struct {
+	Height uint64
+	Tx     metatx.Transactable
+	// Expected describes the outcome of the real application: the hash of
+	// the resulting metastate, or the error returned.
+	Expected string
+	// Got describes the outcome of the diverging trial
+	Got string
+	// Diff shows the differing subtrees of the resulting metastates, if
+	// both applications succeeded
+	Diff string
+}
+
+// A DeterminismHook responds to a determinism violation
+type DeterminismHook func(violation DeterminismViolation)
+
+// SetDeterminismCheck enables the determinism check of DeliverTx
+//
+// Setting nil disables the check. Enabling it is an error unless the app was
+// built with the determinism build tag.
+func (app *App) SetDeterminismCheck(check *DeterminismCheck) error {
+	if check != nil && !determinismCheckAvailable {
+		return errors.New("the determinism check requires the determinism build tag")
+	}
+	app.determinismCheck = check
+	return nil
+}
+
+// applyOutcome is the result of applying a tx
+type applyOutcome struct {
+	state nt.Value
+	err   error
+}
+
+func (o applyOutcome) String() string {
+	if o.err != nil {
+		return "error: " + o.err.Error()
+	}
+	return o.state.Hash().String()
+}
+
+func (o applyOutcome) equals(other applyOutcome) bool {
+	if o.err != nil || other.err != nil {
+		return (o.err == nil) == (other.err == nil)
+	}
+	return o.state.Equals(other.state)
+}
+
+// applyTxChecked applies a tx, first running the determinism check if it
+// is enabled
+func (app *App) applyTxChecked(tx metatx.Transactable, logger log.FieldLogger) error {
+	if app.determinismCheck == nil {
+		return app.applyTx(tx)
+	}
+
+	base, err := app.state.MarshalNoms(app.db)
+	if err != nil {
+		logger.WithError(err).Error("determinism check: marshaling state")
+		return app.applyTx(tx)
+	}
+
+	trials := app.determinismCheck.Trials
+	if trials < 1 {
+		trials = 1
+	}
+	outcomes := make([]applyOutcome, 0, trials)
+	for i := 0; i < trials; i++ {
+		var rng *rand.Rand
+		if app.determinismCheck.ShuffleMaps {
+			rng = rand.New(rand.NewSource(int64(i) + 1))
+		}
+		outcome, err := app.trialApply(tx, base, rng)
+		if err != nil {
+			logger.WithError(err).Error("determinism check: trial failed")
+			return app.applyTx(tx)
+		}
+		outcomes = append(outcomes, outcome)
+	}
+
+	expect := applyOutcome{err: app.applyTx(tx)}
+	if expect.err == nil {
+		expect.state, err = app.state.MarshalNoms(app.db)
+		if err != nil {
+			logger.WithError(err).Error("determinism check: marshaling state")
+			return nil
+		}
+	}
+
+	for _, outcome := range outcomes {
+		if outcome.equals(expect) {
+			continue
+		}
+		violation := DeterminismViolation{
+			Height:   app.Height(),
+			Tx:       tx,
+			Expected: expect.String(),
+			Got:      outcome.String(),
+		}
+		if expect.err == nil && outcome.err == nil {
+			buf := new(bytes.Buffer)
+			if err := diff.PrintDiff(buf, outcome.state, expect.state, false); err == nil {
+				violation.Diff = buf.String()
+			}
+		}
+		logger.WithFields(log.Fields{
+			"determinism.expected": violation.Expected,
+			"determinism.got":      violation.Got,
+			"determinism.diff":     violation.Diff,
+		}).Error("determinism violation")
+		if app.determinismCheck.Hook != nil {
+			app.determinismCheck.Hook(violation)
+		}
+		// one report per tx is enough
+		break
+	}
+	return expect.err
+}
+
+// trialApply applies a tx to an independent copy of the base metastate
+//
+// If rng is set, the maps of the copy's child state are shuffled with it.
+// The app is left as it was found.
+func (app *App) trialApply(tx metatx.Transactable, base nt.Value, rng *rand.Rand) (outcome applyOutcome, err error) {
+	trial := metast.Metastate{ChildState: app.newChildState()}
+	err = trial.UnmarshalNoms(base)
+	if err != nil {
+		return
+	}
+	if rng != nil {
+		shuffleMaps(reflect.ValueOf(trial.ChildState), rng)
+	}
+
+	live := app.state
+	valUpdates := append([]abci.ValidatorUpdate(nil), app.ValUpdates...)
+	defer func() {
+		app.state = live
+		app.ValUpdates = valUpdates
+		app.deferredThunks = nil
+	}()
+
+	app.state = trial
+	app.deferredThunks = nil
+	outcome.err = app.applyTx(tx)
+	if outcome.err == nil {
+		outcome.state, err = app.state.MarshalNoms(app.db)
+	}
+	return
+}
+
+// shuffleMaps replaces every settable map reachable from v through exported
+// fields, pointers, interfaces, slices and arrays with a copy whose entries
+// were inserted in a random order
+//
+// The result is equal to the original, so a deterministic tx has the same
+// outcome whether or not its state was shuffled.
+func shuffleMaps(v reflect.Value, rng *rand.Rand) {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if !v.IsNil() {
+			shuffleMaps(v.Elem(), rng)
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if field := v.Field(i); field.CanSet() {
+				shuffleMaps(field, rng)
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			shuffleMaps(v.Index(i), rng)
+		}
+	case reflect.Map:
+		if v.IsNil() || !v.CanSet() {
+			return
+		}
+		keys := v.MapKeys()
+		rng.Shuffle(len(keys), func(i, j int) {
+			keys[i], keys[j] = keys[j], keys[i]
+		})
+		shuffled := reflect.MakeMapWithSize(v.Type(), len(keys))
+		for _, key := range keys {
+			elem := reflect.New(v.Type().Elem()).Elem()
+			elem.Set(v.MapIndex(key))
+			shuffleMaps(elem, rng)
+			shuffled.SetMapIndex(key, elem)
+		}
+		v.Set(shuffled)
+	}
+}
@@ -0,0 +1,50 @@
+package app
+
+// ----- ---- --- -- -
+// Copyright 2019, 2020 The Axiom Foundation. All Rights Reserved.
+//
+// Licensed under the Apache License 2.0 (the "License").  You may not use
+// this file except in compliance with the License.  You can obtain a copy
+// in the file LICENSE in the source distribution or at
+// https://www.apache.org/licenses/LICENSE-2.0.txt
+// - -- --- ---- -----
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type shuffleState struct {
+	Flat       map[string]int
+	Nested     []map[int]map[string]bool
+	Pointer    *shuffleState
+	unsettable map[string]int
+}
+
+func TestShuffleMapsPreservesContents(t *testing.T) {
+	newState := func() *shuffleState {
+		st := &shuffleState{
+			Flat:       make(map[string]int),
+			Nested:     []map[int]map[string]bool{{1: {"a": true}, 2: {"b": false}}},
+			Pointer:    &shuffleState{Flat: map[string]int{"x": 1}},
+			unsettable: map[string]int{"y": 2},
+		}
+		for i := 0; i < 100; i++ {
+			st.Flat[string(rune('a'+i%26))+string(rune('A'+i/26))] = i
+		}
+		return st
+	}
+
+	expect := newState()
+	for seed := int64(1); seed <= 3; seed++ {
+		st := newState()
+		flat := st.Flat
+		shuffleMaps(reflect.ValueOf(st), rand.New(rand.NewSource(seed)))
+		require.Equal(t, expect, st)
+		// the maps were rebuilt, not shuffled in place
+		require.NotEqual(t, reflect.ValueOf(flat).Pointer(), reflect.ValueOf(st.Flat).Pointer())
+	}
+}
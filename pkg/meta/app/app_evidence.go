@@ -0,0 +1,67 @@
+package app
+
+// ----- ---- --- -- -
+// Copyright 2019, 2020 The Axiom Foundation. All Rights Reserved.
+//
+// Licensed under the Apache License 2.0 (the "License").  You may not use
+// this file except in compliance with the License.  You can obtain a copy
+// in the file LICENSE in the source distribution or at
+// https://www.apache.org/licenses/LICENSE-2.0.txt
+// - -- --- ---- -----
+
+import (
+	metast "github.com/ndau/metanode/pkg/meta/state"
+	log "github.com/sirupsen/logrus"
+	abci "github.com/tendermint/tendermint/abci/types"
+)
+
+// A SlashingHook responds to evidence of byzantine validation.
+//
+// `app` will always be an instance of the child app. Hooks will typically
+// reduce the offending validator's power via App.UpdateValidator.
+type SlashingHook func(app interface{}, evidence metast.Evidence) error
+
+// SetSlashingHook sets the hook called for each piece of byzantine evidence
+//
+// Setting a nil hook disables slashing.
+func (app *App) SetSlashingHook(hook SlashingHook) {
+	app.slashingHook = hook
+}
+
+// TrackEvidence begins persisting byzantine evidence in the metastate.
+//
+// See metast.Metastate.TrackEvidence for when this should be called.
+func (app *App) TrackEvidence() {
+	app.state.TrackEvidence()
+}
+
+// GetEvidence returns all persisted byzantine evidence, oldest first.
+func (app *App) GetEvidence() []metast.Evidence {
+	return app.state.GetEvidence()
+}
+
+// handleEvidence records the request's byzantine evidence and calls the
+// slashing hook for each piece of it.
+//
+// It must be called after the validator updates have been reset for the block.
+func (app *App) handleEvidence(logger log.FieldLogger, req abci.RequestBeginBlock) {
+	evidence := app.state.RecordEvidence(req)
+	if len(evidence) > 0 && app.state.IsTrackingEvidence() {
+		// evidence must be persisted even if this block contains no transactions
+		app.transactionsPending++
+	}
+	if app.slashingHook == nil {
+		return
+	}
+	app.checkChild()
+	for _, ev := range evidence {
+		err := app.slashingHook(app.childApp, ev)
+		if err != nil {
+			logger.WithError(err).WithFields(log.Fields{
+				"evidence.type":      ev.Type,
+				"evidence.height":    ev.Height,
+				"evidence.validator": ev.Validator,
+			}).Error("slashing hook failed")
+		}
+	}
+}
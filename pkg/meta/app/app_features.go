@@ -0,0 +1,144 @@
+package app
+
+// ----- ---- --- -- -
+// Copyright 2019, 2020 The Axiom Foundation. All Rights Reserved.
+//
+// Licensed under the Apache License 2.0 (the "License").  You may not use
+// this file except in compliance with the License.  You can obtain a copy
+// in the file LICENSE in the source distribution or at
+// https://www.apache.org/licenses/LICENSE-2.0.txt
+// - -- --- ---- -----
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"sort"
+
+	metast "github.com/ndau/metanode/pkg/meta/state"
+	"github.com/pkg/errors"
+)
+
+// A FeatureSchedule maps feature names to the tendermint heights at which
+// they activate.
+//
+// Features are changes in chain behavior. Historical blocks must replay with
+// the behavior in effect when they were produced, so every node on a chain
+// must use the same schedule.
+type FeatureSchedule map[string]uint64
+
+// LoadFeatureSchedule reads a feature schedule from a JSON configuration file
+//
+// The file must contain a single object mapping feature names to activation
+// heights:
+//
+//	{"StrictUpdates": 120000}
+func LoadFeatureSchedule(path string) (FeatureSchedule, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "reading feature schedule")
+	}
+	schedule := make(FeatureSchedule)
+	err = json.Unmarshal(data, &schedule)
+	if err != nil {
+		return nil, errors.Wrap(err, "parsing feature schedule")
+	}
+	return schedule, nil
+}
+
+// Names returns the names of the features in the schedule, sorted
+func (fs FeatureSchedule) Names() []string {
+	names := make([]string, 0, len(fs))
+	for name := range fs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// RegisterFeature declares a feature and its default activation height
+//
+// Child apps should register all their features during initialization,
+// before configuration overrides are applied. A feature with activation
+// height 0 is active from genesis.
+func (app *App) RegisterFeature(name string, height uint64) error {
+	if name == "" {
+		return errors.New("feature name must not be empty")
+	}
+	if app.features == nil {
+		app.features = make(FeatureSchedule)
+	}
+	if _, exists := app.features[name]; exists {
+		return fmt.Errorf("feature %s is already registered", name)
+	}
+	app.features[name] = height
+	return nil
+}
+
+// ConfigureFeatures overrides the activation heights of registered features
+//
+// This is typically used to apply a schedule read by LoadFeatureSchedule.
+// It is an error to configure a feature which has not been registered; in
+// that case, no overrides are applied.
+func (app *App) ConfigureFeatures(overrides FeatureSchedule) error {
+	for _, name := range overrides.Names() {
+		if _, exists := app.features[name]; !exists {
+			return fmt.Errorf("cannot configure unregistered feature %s", name)
+		}
+	}
+	for name, height := range overrides {
+		app.features[name] = height
+	}
+	return nil
+}
+
+// GetFeatureSchedule returns a copy of the app's feature schedule
+func (app *App) GetFeatureSchedule() FeatureSchedule {
+	schedule := make(FeatureSchedule, len(app.features))
+	for name, height := range app.features {
+		schedule[name] = height
+	}
+	return schedule
+}
+
+// FeatureActive is true when the named feature is active at the current height
+//
+// It returns an error if the feature has not been registered: silently treating
+// a misspelled feature as inactive would change chain behavior. Code which runs
+// during consensus should use this rather than IsFeatureActive, so that the
+// error can be surfaced through the usual ABCI response.
+func (app *App) FeatureActive(name string) (bool, error) {
+	height, exists := app.features[name]
+	if !exists {
+		return false, fmt.Errorf("feature %s is not registered", name)
+	}
+	return app.Height() >= height, nil
+}
+
+// IsFeatureActive is true when the named feature is active at the current height
+//
+// It panics if the feature has not been registered; see FeatureActive. It is
+// intended for feature names which are compile-time constants registered
+// during initialization.
+func (app *App) IsFeatureActive(name string) bool {
+	active, err := app.FeatureActive(name)
+	if err != nil {
+		panic(err.Error())
+	}
+	return active
+}
+
+// UpdateStateGated updates the child application state, leaking updater
+// failures until the named feature activates
+//
+// This replaces the per-chain switch between UpdateStateLeaky and UpdateState:
+// before the feature's activation height, it behaves like UpdateStateLeaky;
+// from the activation height on, it behaves like UpdateState. If the feature
+// has not been registered, it returns an error without updating the state.
+func (app *App) UpdateStateGated(feature string, updaters ...func(state metast.State) (metast.State, error)) error {
+	active, err := app.FeatureActive(feature)
+	if err != nil {
+		return errors.Wrap(err, "UpdateStateGated")
+	}
+	return app.updateStateInner(!active, updaters...)
+}
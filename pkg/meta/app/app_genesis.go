@@ -0,0 +1,294 @@
+package app
+
+// ----- ---- --- -- -
+// Copyright 2019, 2020 The Axiom Foundation. All Rights Reserved.
+//
+// Licensed under the Apache License 2.0 (the "License").  You may not use
+// this file except in compliance with the License.  You can obtain a copy
+// in the file LICENSE in the source distribution or at
+// https://www.apache.org/licenses/LICENSE-2.0.txt
+// - -- --- ---- -----
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"time"
+
+	metast "github.com/ndau/metanode/pkg/meta/state"
+	"github.com/pkg/errors"
+	tmtypes "github.com/tendermint/tendermint/types"
+	"github.com/tinylib/msgp/msgp"
+)
+
+// A GenesisDecoder populates the initial child state from the app state
+// bytes of the genesis document.
+//
+// `state` is a freshly initialized instance of the child state. The decoder
+// may modify and return it, or return a different instance of the same type.
+// Decoders must be deterministic: InitChain panics if decoding the same bytes
+// twice produces states with different hashes.
+type GenesisDecoder func(appStateBytes []byte, state metast.State) (metast.State, error)
+
+// JSONGenesisDecoder decodes app state bytes as JSON directly into the child state
+func JSONGenesisDecoder(appStateBytes []byte, state metast.State) (metast.State, error) {
+	decoder := json.NewDecoder(bytes.NewReader(appStateBytes))
+	decoder.DisallowUnknownFields()
+	err := decoder.Decode(state)
+	return state, errors.Wrap(err, "decoding JSON app state")
+}
+
+// MsgpGenesisDecoder decodes msgp app state bytes directly into the child state
+//
+// Tendermint requires the genesis app state to be JSON, so msgp app state is
+// stored as a JSON string containing the base64-encoded msgp. The child state
+// must implement msgp.Unmarshaler.
+func MsgpGenesisDecoder(appStateBytes []byte, state metast.State) (metast.State, error) {
+	unmarshaler, ok := state.(msgp.Unmarshaler)
+	if !ok {
+		return nil, fmt.Errorf("%T does not implement msgp.Unmarshaler", state)
+	}
+	var msgpBytes []byte
+	err := json.Unmarshal(appStateBytes, &msgpBytes)
+	if err != nil {
+		return nil, errors.Wrap(err, "decoding msgp app state from JSON")
+	}
+	leftovers, err := unmarshaler.UnmarshalMsg(msgpBytes)
+	if err != nil {
+		return nil, errors.Wrap(err, "decoding msgp app state")
+	}
+	if len(leftovers) > 0 {
+		return nil, errors.New("decoding msgp app state produced leftover bytes")
+	}
+	return state, nil
+}
+
+// A GenesisEncoder encodes the child state as genesis app state bytes.
+//
+// It is the inverse of a GenesisDecoder. The output must be valid JSON.
+type GenesisEncoder func(state metast.State) ([]byte, error)
+
+// JSONGenesisEncoder encodes the child state as JSON
+func JSONGenesisEncoder(state metast.State) ([]byte, error) {
+	appStateBytes, err := json.Marshal(state)
+	return appStateBytes, errors.Wrap(err, "encoding JSON app state")
+}
+
+// MsgpGenesisEncoder encodes the child state as a JSON string containing
+// base64-encoded msgp
+//
+// The child state must implement msgp.Marshaler.
+func MsgpGenesisEncoder(state metast.State) ([]byte, error) {
+	marshaler, ok := state.(msgp.Marshaler)
+	if !ok {
+		return nil, fmt.Errorf("%T does not implement msgp.Marshaler", state)
+	}
+	msgpBytes, err := marshaler.MarshalMsg(nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "encoding msgp app state")
+	}
+	appStateBytes, err := json.Marshal(msgpBytes)
+	return appStateBytes, errors.Wrap(err, "encoding msgp app state as JSON")
+}
+
+// SetGenesisDecoder sets the decoder used to import the genesis app state.
+//
+// It must be called before InitChain. If no decoder is set, the genesis app
+// state is ignored.
+//
+// Limitation: the app hash resulting from InitChain should be returned to
+// tendermint, so that nodes which imported different genesis states can't
+// start a chain together. ResponseInitChain has no AppHash field before
+// tendermint 0.34, so this isn't possible with tendermint 0.33. Instead,
+// InitChain logs the hash, and GenesisAppHash returns it: operators should
+// check that it is the same on every node before the chain starts.
+// InitChain's check that the decoder is deterministic can only detect
+// nondeterminism within a single process.
+func (app *App) SetGenesisDecoder(decoder GenesisDecoder) {
+	app.genesisDecoder = decoder
+}
+
+// GenesisAppHash returns the app hash resulting from InitChain
+//
+// It is nil if InitChain has not been called by this process.
+func (app *App) GenesisAppHash() []byte {
+	return app.genesisAppHash
+}
+
+// GenesisAppStateVersion is the version of the app state envelope written by
+// ExportGenesis
+const GenesisAppStateVersion = 1
+
+// GenesisAppState is the envelope in which ExportGenesis stores the app state
+//
+// It carries the metastate's managed variables, including the schema version,
+// alongside the child state, so that a chain restarted from an export has the
+// same metastate as the chain which exported it, and doesn't rerun migrations
+// which its child state has already undergone. Child holds the output of the export's GenesisEncoder,
+// and is what the GenesisDecoder receives on import.
+//
+// App state which is not an envelope, as in a hand-written genesis document,
+// is passed to the GenesisDecoder as-is.
+type GenesisAppState struct {
+	Version   uint64                  `json:"version"`
+	Metastate metast.GenesisMetastate `json:"metastate"`
+	Child     json.RawMessage         `json:"child"`
+}
+
+// parseGenesisAppState returns the envelope of the app state bytes, or nil
+// if they are not an envelope
+func parseGenesisAppState(appStateBytes []byte) (*GenesisAppState, error) {
+	var envelope GenesisAppState
+	decoder := json.NewDecoder(bytes.NewReader(appStateBytes))
+	decoder.DisallowUnknownFields()
+	if decoder.Decode(&envelope) != nil || envelope.Version == 0 || len(envelope.Child) == 0 {
+		return nil, nil
+	}
+	if envelope.Version > GenesisAppStateVersion {
+		return nil, fmt.Errorf(
+			"genesis app state version %d is newer than supported version %d",
+			envelope.Version, GenesisAppStateVersion,
+		)
+	}
+	return &envelope, nil
+}
+
+// newChildState creates a new, initialized instance of the child state type
+func (app *App) newChildState() metast.State {
+	stateType := reflect.Indirect(reflect.ValueOf(app.GetState())).Type()
+	state := reflect.New(stateType).Interface().(metast.State)
+	state.Init(app.db)
+	return state
+}
+
+// importGenesis decodes the genesis app state into the child state, and
+// restores the metastate's managed variables if it is an envelope
+//
+// The child state is decoded twice, into independent instances, and the noms
+// hashes of the results are compared, so that a nondeterministic decoder
+// fails at genesis instead of forking the chain later.
+func (app *App) importGenesis(appStateBytes []byte) error {
+	envelope, err := parseGenesisAppState(appStateBytes)
+	if err != nil {
+		return err
+	}
+	if envelope != nil {
+		appStateBytes = envelope.Child
+	}
+
+	var states [2]metast.State
+	for i := range states {
+		state, err := app.genesisDecoder(appStateBytes, app.newChildState())
+		if err != nil {
+			return err
+		}
+		if state == nil {
+			return errors.New("nil state returned from genesis decoder")
+		}
+		states[i] = state
+	}
+
+	a, err := states[0].MarshalNoms(app.db)
+	if err != nil {
+		return errors.Wrap(err, "marshaling genesis state")
+	}
+	b, err := states[1].MarshalNoms(app.db)
+	if err != nil {
+		return errors.Wrap(err, "marshaling genesis state")
+	}
+	if a.Hash() != b.Hash() {
+		return fmt.Errorf(
+			"genesis decoder is nondeterministic: got hashes %s and %s",
+			a.Hash(), b.Hash(),
+		)
+	}
+
+	app.state.ChildState = states[0]
+	if envelope != nil {
+		app.state.ImportGenesis(envelope.Metastate)
+	}
+	return nil
+}
+
+// GenesisExport configures ExportGenesis
+type GenesisExport struct {
+	// Height is the tendermint height to export. 0 means the current height.
+	Height uint64
+	// ChainID is the chain ID of the genesis document
+	ChainID string
+	// GenesisTime is the genesis time of the genesis document.
+	// If unset, the current block time is used.
+	GenesisTime time.Time
+	// Encoder encodes the child state. If unset, JSONGenesisEncoder is used.
+	Encoder GenesisEncoder
+}
+
+// ExportGenesis exports the committed state as of a given height as a
+// tendermint genesis document.
+//
+// The genesis document contains the validator set, the consensus params if
+// they are tracked, and a GenesisAppState envelope holding the metastate's
+// managed variables and the child state as the app state. A chain restarted
+// from it must set the genesis decoder corresponding to the export's encoder.
+func (app *App) ExportGenesis(export GenesisExport) (*tmtypes.GenesisDoc, error) {
+	if export.Encoder == nil {
+		export.Encoder = JSONGenesisEncoder
+	}
+	if export.GenesisTime.IsZero() {
+		export.GenesisTime = app.blockTime.AsTime()
+	}
+
+	metastate, err := metast.MetastateAtHeight(app.db, app.ds, app.newChildState(), export.Height)
+	if err != nil {
+		return nil, errors.Wrap(err, "ExportGenesis: getting metastate")
+	}
+
+	doc := tmtypes.GenesisDoc{
+		GenesisTime: export.GenesisTime,
+		ChainID:     export.ChainID,
+	}
+
+	validators, err := metastate.GetValidatorInfos()
+	if err != nil {
+		return nil, errors.Wrap(err, "ExportGenesis: getting validators")
+	}
+	for _, vi := range validators {
+		pk, err := tmtypes.PB2TM.PubKey(vi.PubKey)
+		if err != nil {
+			return nil, errors.Wrap(err, "ExportGenesis: converting validator pubkey")
+		}
+		doc.Validators = append(doc.Validators, tmtypes.GenesisValidator{
+			Address: pk.Address(),
+			PubKey:  pk,
+			Power:   vi.Power,
+		})
+	}
+
+	if metastate.IsTrackingConsensusParams() {
+		cp := tmtypes.DefaultConsensusParams().Update(metastate.GetConsensusParams().ABCI())
+		doc.ConsensusParams = &cp
+	}
+
+	child, err := export.Encoder(metastate.ChildState)
+	if err != nil {
+		return nil, errors.Wrap(err, "ExportGenesis")
+	}
+	if !json.Valid(child) {
+		return nil, errors.New("ExportGenesis: encoder produced invalid JSON")
+	}
+	doc.AppState, err = json.Marshal(GenesisAppState{
+		Version:   GenesisAppStateVersion,
+		Metastate: metastate.ExportGenesis(),
+		Child:     child,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "ExportGenesis: encoding app state")
+	}
+
+	err = doc.ValidateAndComplete()
+	if err != nil {
+		return nil, errors.Wrap(err, "ExportGenesis: invalid genesis document")
+	}
+	return &doc, nil
+}
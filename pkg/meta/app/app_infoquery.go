@@ -46,6 +46,7 @@ var queryHandlers map[string]func(app interface{}, request abci.RequestQuery, re
 
 func init() {
 	queryHandlers = make(map[string]func(app interface{}, request abci.RequestQuery, response *abci.ResponseQuery))
+	RegisterQueryHandler(ConsensusParamsEndpoint, consensusParamsQuery)
 }
 
 // RegisterQueryHandler registers a query handler at a particular endpoint
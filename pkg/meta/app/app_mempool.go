@@ -21,12 +21,14 @@ import (
 	abci "github.com/tendermint/tendermint/abci/types"
 )
 
-func (app *App) validateTransactable(bytes []byte) (metatx.Transactable, uint32, log.FieldLogger, error) {
+// validateTransactable decodes and validates a tx which would be included in
+// the block at the given height
+func (app *App) validateTransactable(bytes []byte, height uint64) (metatx.Transactable, uint32, log.FieldLogger, error) {
 	if app.childStateValidity != nil {
 		return nil, uint32(code.InvalidNodeState), app.logger.WithError(app.childStateValidity), app.invalidChildStateError()
 	}
 
-	tx, err := metatx.Unmarshal(bytes, app.txIDs)
+	tx, err := metatx.UnmarshalWithResolver(bytes, app.txIDs)
 	rc := uint32(code.OK)
 	if err != nil {
 		logger := app.logger.WithError(err).WithField("tx.bytes", fmt.Sprintf("%x", bytes))
@@ -34,6 +36,9 @@ func (app *App) validateTransactable(bytes []byte) (metatx.Transactable, uint32,
 		return nil, uint32(code.EncodingError), logger, err
 	}
 	logger := app.DecoratedTxLogger(tx)
+	if rc, err := app.checkTxLifecycle(tx, height, logger); err != nil {
+		return nil, rc, logger, err
+	}
 	app.checkChild()
 	err = tx.Validate(app.childApp)
 	if err != nil {
@@ -44,9 +49,72 @@ func (app *App) validateTransactable(bytes []byte) (metatx.Transactable, uint32,
 	return tx, rc, logger, nil
 }
 
+// checkTxLifecycle enforces the lifecycle metadata of the tx's type, for a tx
+// which would be included in the block at the given height
+//
+// Only a *metatx.Registry carries lifecycle metadata; with a plain TxIDMap,
+// every known type is always accepted.
+func (app *App) checkTxLifecycle(tx metatx.Transactable, height uint64, logger log.FieldLogger) (uint32, error) {
+	registry, ok := app.txIDs.(*metatx.Registry)
+	if !ok {
+		return uint32(code.OK), nil
+	}
+	id, err := registry.TxIDOf(tx)
+	if err != nil {
+		// can't happen: the registry just decoded this tx
+		return uint32(code.EncodingError), err
+	}
+	err = registry.CheckActive(id, height)
+	switch err.(type) {
+	case nil:
+	case metatx.ErrSunset:
+		logger.WithError(err).Info("deprecated tx")
+		return uint32(code.DeprecatedTransaction), err
+	case metatx.ErrNotYetValid:
+		logger.WithError(err).Info("tx not yet valid")
+		return uint32(code.TransactionNotYetValid), err
+	default:
+		return uint32(code.InvalidTransaction), err
+	}
+	if md, _ := registry.Metadata(id); md.IsDeprecated() {
+		logger.WithFields(log.Fields{
+			"tx.deprecation": md.Deprecation,
+			"tx.validUntil":  md.ValidUntil,
+		}).Warn("deprecated tx type")
+	}
+	return uint32(code.OK), nil
+}
+
+// checkTxLimits rejects serialized transactions which exceed the app's limits
+// before they are decoded
+func (app *App) checkTxLimits(bytes []byte) (uint32, log.FieldLogger, error) {
+	err := metatx.CheckLimits(bytes, app.txLimits)
+	if err == nil {
+		return uint32(code.OK), app.logger, nil
+	}
+	logger := app.logger.WithError(err).WithField("tx.len", len(bytes))
+	if _, ok := err.(metatx.ErrLimitExceeded); ok {
+		logger.Info("tx exceeds limits")
+		return uint32(code.TransactionLimitExceeded), logger, err
+	}
+	logger.Info("Encoding error")
+	return uint32(code.EncodingError), logger, err
+}
+
 // CheckTx validates a Transaction
+//
+// Unlike DeliverTx, CheckTx enforces the app's transaction limits. Rejecting
+// transactions in DeliverTx would change the rules by which historical blocks
+// replay.
+//
+// The app's height is that of the last committed block, so transactions are
+// checked against the height of the next block, in which they would be
+// included at the earliest.
 func (app *App) CheckTx(request abci.RequestCheckTx) (response abci.ResponseCheckTx) {
-	_, rc, logger, err := app.validateTransactable(request.Tx)
+	rc, logger, err := app.checkTxLimits(request.Tx)
+	if err == nil {
+		_, rc, logger, err = app.validateTransactable(request.Tx, app.Height()+1)
+	}
 	app.logRequest("CheckTx", logger)
 	response.Code = rc
 	if err != nil {
@@ -0,0 +1,134 @@
+package app
+
+// ----- ---- --- -- -
+// Copyright 2019, 2020 The Axiom Foundation. All Rights Reserved.
+//
+// Licensed under the Apache License 2.0 (the "License").  You may not use
+// this file except in compliance with the License.  You can obtain a copy
+// in the file LICENSE in the source distribution or at
+// https://www.apache.org/licenses/LICENSE-2.0.txt
+// - -- --- ---- -----
+
+import (
+	"fmt"
+
+	metast "github.com/ndau/metanode/pkg/meta/state"
+	"github.com/ndau/noms/go/hash"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// A Migration transforms the child state from one schema version to the next
+//
+// The nomsify-generated unmarshalers silently ignore unknown fields and zero
+// missing ones, so a change to the child state's structure which is not
+// covered by the managed var convention needs an explicit migration.
+type Migration struct {
+	// Version is the schema version the migration produces. Migrations must
+	// be registered in order, starting from version 1.
+	Version uint64
+	// Height is the tendermint height at whose BeginBlock the migration runs.
+	// It must not have been reached when the migration is first deployed.
+	Height uint64
+	// Migrate transforms the child state. It must be deterministic.
+	Migrate func(state metast.State) (metast.State, error)
+}
+
+// RegisterMigration adds a migration to the app
+//
+// Child apps should register all their migrations during initialization,
+// including those which have already run: the schema version recorded in the
+// metastate ensures that each migration is applied only once.
+func (app *App) RegisterMigration(migration Migration) error {
+	if migration.Migrate == nil {
+		return errors.New("migration must have a Migrate function")
+	}
+	var prev Migration
+	if len(app.migrations) > 0 {
+		prev = app.migrations[len(app.migrations)-1]
+	}
+	if migration.Version != prev.Version+1 {
+		return fmt.Errorf(
+			"migration to schema version %d registered out of order: expected version %d",
+			migration.Version, prev.Version+1,
+		)
+	}
+	if migration.Height < prev.Height {
+		return fmt.Errorf(
+			"migration to schema version %d at height %d precedes previous migration at height %d",
+			migration.Version, migration.Height, prev.Height,
+		)
+	}
+	app.migrations = append(app.migrations, migration)
+	return nil
+}
+
+// SchemaVersion returns the current schema version of the child state
+func (app *App) SchemaVersion() uint64 {
+	return app.state.GetSchemaVersion()
+}
+
+// applyMigration runs a migration with a determinism check
+//
+// The migration is applied to two independent copies of the child state, and
+// the noms hashes of the results are compared. Nodes which disagree here
+// would otherwise fork at the migration height.
+//
+// It returns the migrated state and its hash.
+func (app *App) applyMigration(migration Migration) (metast.State, hash.Hash, error) {
+	var results [2]metast.State
+	for i := range results {
+		state, err := app.cloneChildState(app.GetState())
+		if err != nil {
+			return nil, hash.Hash{}, err
+		}
+		state, err = migration.Migrate(state)
+		if err != nil {
+			return nil, hash.Hash{}, err
+		}
+		if state == nil {
+			return nil, hash.Hash{}, errors.New("nil state returned from migration")
+		}
+		results[i] = state
+	}
+
+	a, err := results[0].MarshalNoms(app.db)
+	if err != nil {
+		return nil, hash.Hash{}, errors.Wrap(err, "marshaling migrated state")
+	}
+	b, err := results[1].MarshalNoms(app.db)
+	if err != nil {
+		return nil, hash.Hash{}, errors.Wrap(err, "marshaling migrated state")
+	}
+	if a.Hash() != b.Hash() {
+		return nil, hash.Hash{}, fmt.Errorf(
+			"migration is nondeterministic: got hashes %s and %s",
+			a.Hash(), b.Hash(),
+		)
+	}
+	return results[0], a.Hash(), nil
+}
+
+// runMigrations applies all registered migrations due at the current height
+func (app *App) runMigrations(logger log.FieldLogger) {
+	for _, migration := range app.migrations {
+		if migration.Version <= app.SchemaVersion() || migration.Height > app.Height() {
+			continue
+		}
+		mlogger := logger.WithFields(log.Fields{
+			"migration.version": migration.Version,
+			"migration.height":  migration.Height,
+		})
+		state, stateHash, err := app.applyMigration(migration)
+		if err != nil {
+			mlogger.WithError(err).Error("schema migration failed")
+			// continuing with an unmigrated state would fork the chain
+			panic(err.Error())
+		}
+		app.state.ChildState = state
+		app.state.SetSchemaVersion(migration.Version)
+		// the migration must be persisted even if this block has no transactions
+		app.transactionsPending++
+		mlogger.WithField("migration.hash", stateHash.String()).Info("applied schema migration")
+	}
+}
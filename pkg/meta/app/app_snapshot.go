@@ -0,0 +1,88 @@
+package app
+
+// ----- ---- --- -- -
+// Copyright 2019, 2020 The Axiom Foundation. All Rights Reserved.
+//
+// Licensed under the Apache License 2.0 (the "License").  You may not use
+// this file except in compliance with the License.  You can obtain a copy
+// in the file LICENSE in the source distribution or at
+// https://www.apache.org/licenses/LICENSE-2.0.txt
+// - -- --- ---- -----
+
+import (
+	"github.com/ndau/metanode/pkg/meta/app/internal/snapshot"
+	metast "github.com/ndau/metanode/pkg/meta/state"
+	"github.com/pkg/errors"
+	abci "github.com/tendermint/tendermint/abci/types"
+)
+
+// Snapshots rewind the app, including the head of its dataset, to the moment
+// they were taken. This lets tests share an expensive setup between cases, but
+// production code must never rewind history, so they are exposed only to
+// apptest, through the hooks of the internal snapshot package.
+//
+// Nothing is ever deleted from a noms database, so a snapshot remains valid
+// however far the app advances after it is taken. It can only be restored
+// into the app which took it.
+func init() {
+	snapshot.Take = func(app interface{}) (*snapshot.Snapshot, error) {
+		return app.(*App).takeSnapshot()
+	}
+	snapshot.Restore = func(app interface{}, s *snapshot.Snapshot) error {
+		return app.(*App).restoreSnapshot(s)
+	}
+}
+
+// takeSnapshot takes a snapshot of the app
+func (app *App) takeSnapshot() (*snapshot.Snapshot, error) {
+	state, err := app.state.MarshalNoms(app.db)
+	if err != nil {
+		return nil, errors.Wrap(err, "Snapshot: marshaling state")
+	}
+	head, hasHead := app.ds.MaybeHeadRef()
+	return &snapshot.Snapshot{
+		App:                 app,
+		Head:                head,
+		HasHead:             hasHead,
+		State:               state,
+		Height:              app.height,
+		BlockTime:           app.blockTime,
+		TransactionsPending: app.transactionsPending,
+		ValUpdates:          append([]abci.ValidatorUpdate(nil), app.ValUpdates...),
+	}, nil
+}
+
+// restoreSnapshot rewinds the app to a snapshot
+func (app *App) restoreSnapshot(s *snapshot.Snapshot) error {
+	if s == nil || s.App != app {
+		return errors.New("Restore: snapshot was not taken by this app")
+	}
+	if app.readOnly {
+		return errors.Wrap(ErrReadOnly, "Restore")
+	}
+
+	state := metast.Metastate{ChildState: app.newChildState()}
+	err := state.UnmarshalNoms(s.State)
+	if err != nil {
+		return errors.Wrap(err, "Restore: unmarshaling state")
+	}
+
+	ds := app.ds
+	if s.HasHead {
+		ds, err = app.db.SetHead(ds, s.Head)
+	} else if _, hasHead := ds.MaybeHeadRef(); hasHead {
+		ds, err = app.db.Delete(ds)
+	}
+	if err != nil {
+		return errors.Wrap(err, "Restore: resetting dataset head")
+	}
+
+	app.ds = ds
+	app.state = state
+	app.height = s.Height
+	app.blockTime = s.BlockTime
+	app.transactionsPending = s.TransactionsPending
+	app.ValUpdates = append([]abci.ValidatorUpdate(nil), s.ValUpdates...)
+	app.deferredThunks = nil
+	return nil
+}
@@ -13,6 +13,7 @@ package app
 import (
 	"strings"
 
+	metast "github.com/ndau/metanode/pkg/meta/state"
 	log "github.com/sirupsen/logrus"
 	abci "github.com/tendermint/tendermint/abci/types"
 )
@@ -33,3 +34,34 @@ func (app *App) UpdateValidator(v abci.ValidatorUpdate) {
 		"app.ValUpdates":   strings.Join(vuss, ", "),
 	}).Info("UpdateValidator")
 }
+
+// Validators returns a list of the app's validators.
+//
+// The list is sorted by descending power, then by ascending address.
+func (app *App) Validators() ([]abci.Validator, error) {
+	return app.state.GetValidators()
+}
+
+// ValidatorInfos returns a list of the app's validators, including their public keys.
+//
+// The list is sorted by descending power, then by ascending address.
+func (app *App) ValidatorInfos() ([]metast.ValidatorInfo, error) {
+	return app.state.GetValidatorInfos()
+}
+
+// IndexValidatorAddresses begins maintaining the index between validator pubkeys and addresses.
+//
+// See metast.Metastate.IndexValidatorAddresses for when this should be called.
+func (app *App) IndexValidatorAddresses() error {
+	return app.state.IndexValidatorAddresses()
+}
+
+// ValidatorAddress returns the address of the validator with the given public key
+func (app *App) ValidatorAddress(pk abci.PubKey) ([]byte, error) {
+	return app.state.AddressOf(pk)
+}
+
+// ValidatorPubKey returns the public key of the validator with the given address
+func (app *App) ValidatorPubKey(address []byte) (abci.PubKey, bool, error) {
+	return app.state.PubKeyOf(address)
+}
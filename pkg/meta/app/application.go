@@ -17,16 +17,13 @@ import (
 	"fmt"
 	"os"
 	"os/signal"
-	"reflect"
 	"syscall"
 	"time"
 
 	metast "github.com/ndau/metanode/pkg/meta/state"
 	metatx "github.com/ndau/metanode/pkg/meta/transaction"
 	math "github.com/ndau/ndaumath/pkg/types"
-	"github.com/ndau/noms/go/d"
 	"github.com/ndau/noms/go/datas"
-	"github.com/ndau/noms/go/spec"
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
 	abci "github.com/tendermint/tendermint/abci/types"
@@ -74,8 +71,8 @@ type App struct {
 	// the name of this application
 	name string
 
-	// a map of txid to example struct
-	txIDs metatx.TxIDMap
+	// maps txids to example structs
+	txIDs metatx.TxIDResolver
 
 	// the child application, which defines the transactables and state
 	// we need this to pass through into the transactables' methods.
@@ -129,6 +126,29 @@ type App struct {
 
 	// thunks to be applied at tx's end if application was otherwise successful
 	deferredThunks []Thunk
+
+	// called for each piece of byzantine evidence presented in BeginBlock
+	slashingHook SlashingHook
+
+	// decodes the genesis app state in InitChain
+	genesisDecoder GenesisDecoder
+	// the app hash resulting from InitChain
+	genesisAppHash []byte
+
+	// activation heights of named changes in chain behavior
+	features FeatureSchedule
+
+	// child state schema migrations, in version order
+	migrations []Migration
+
+	// bounds on serialized transactions accepted by CheckTx
+	txLimits metatx.Limits
+
+	// debug mode: apply each tx to independent copies of the state and compare
+	determinismCheck *DeterminismCheck
+
+	// when set, nothing is ever committed to the database
+	readOnly bool
 }
 
 // NewApp prepares a new App
@@ -150,38 +170,52 @@ func NewApp(dbSpec string, name string, childState metast.State, txIDs metatx.Tx
 //   - `childState` is the child state manager. It must be initialized to its zero value.
 //   - `txIDs` is the map of transaction ids to example structs
 func NewAppWithLogger(dbSpec string, name string, childState metast.State, txIDs metatx.TxIDMap, logger log.FieldLogger) (*App, error) {
-	if len(dbSpec) == 0 {
-		dbSpec = "mem"
-	}
-
-	_, err := spec.ForDatabase(dbSpec)
+	storage, err := ParseStorageSpec(dbSpec)
 	if err != nil {
-		fmt.Printf("Could not access database: %s\n", dbSpec)
-		return nil, errors.Wrap(err, "Could not access database")
+		return nil, errors.Wrap(err, "NewApp")
 	}
+	return NewAppWithStorage(storage, name, childState, txIDs, logger)
+}
 
-	db_name := fmt.Sprintf("%s::ndau", dbSpec)
-	sp, err := spec.ForDataset(db_name)
+// NewAppWithRegistry prepares a new App whose transactions are resolved
+// through a registry rather than a plain map
+//
+//   - `dbSpec` is the database spec string; empty or "mem" for in-memory,
+//     the connection path (parseable by noms)
+//   - `name` is the name of this app
+//   - `childState` is the child state manager. It must be initialized to its zero value.
+//   - `registry` maps transaction ids and names to example structs
+//   - `logger` may be nil, in which case a default logger is used
+func NewAppWithRegistry(dbSpec string, name string, childState metast.State, registry *metatx.Registry, logger log.FieldLogger) (*App, error) {
+	storage, err := ParseStorageSpec(dbSpec)
 	if err != nil {
-		fmt.Printf("Could not create dataset: %s - %v\n", db_name, err)
-		return nil, errors.Wrap(err, "NewApp failed to create noms ndau db")
+		return nil, errors.Wrap(err, "NewApp")
 	}
+	return NewAppWithStorage(storage, name, childState, registry, logger)
+}
 
-	var db datas.Database
-	// we can fail to connect to noms for a variety of reasons, catch these here and report error
-	// we use Try() because noms panics in various places
-	err = d.Try(func() {
-		db = sp.GetDatabase()
-	})
+// NewAppWithStorage prepares a new App
+//
+//   - `storage` configures the database
+//   - `name` is the name of this app, and the default name of its dataset
+//   - `childState` is the child state manager. It must be initialized to its zero value.
+//   - `txIDs` maps transaction ids to example structs: a metatx.TxIDMap or *metatx.Registry
+//   - `logger` may be nil, in which case a default logger is used
+func NewAppWithStorage(storage StorageConfig, name string, childState metast.State, txIDs metatx.TxIDResolver, logger log.FieldLogger) (*App, error) {
+	db, err := storage.Open()
 	if err != nil {
-		return nil, errors.Wrap(d.Unwrap(err), fmt.Sprintf("NewApp failed to connect to noms db, is noms running at: %s?", dbSpec))
+		return nil, errors.Wrap(err, "NewApp")
 	}
 
 	// initialize the child state
 	childState.Init(db)
 
 	// in some ways, a dataset is like a particular table in the db
-	ds := db.GetDataset(name)
+	dataset := storage.Dataset
+	if dataset == "" {
+		dataset = name
+	}
+	ds := db.GetDataset(dataset)
 
 	state := metast.Metastate{}
 	ds, err = state.Load(db, ds, childState)
@@ -208,9 +242,32 @@ func NewAppWithLogger(dbSpec string, name string, childState metast.State, txIDs
 		txIDs:     txIDs,
 		height:    state.Height,
 		blockTime: now,
+		txLimits:  metatx.DefaultLimits,
+		readOnly:  storage.ReadOnly,
 	}, nil
 }
 
+// NewReadOnlyApp prepares an App which can never modify its database
+//
+// It loads the existing state from the configured storage, which must not be
+// in memory, and serves queries and the history APIs such as AtHeight.
+// InitChain, BeginBlock, EndBlock, DeliverTx, and Commit leave the state
+// unchanged; they and UpdateStateImmediately fail with ErrReadOnly, as does
+// restoring an apptest snapshot. The database returned by GetDB rejects
+// commits and any other change to a dataset's head with ErrReadOnly. This is
+// useful for query nodes and offline analysis tools.
+//
+// The arguments are as for NewAppWithStorage; storage.ReadOnly is implied.
+func NewReadOnlyApp(storage StorageConfig, name string, childState metast.State, txIDs metatx.TxIDResolver, logger log.FieldLogger) (*App, error) {
+	storage.ReadOnly = true
+	return NewAppWithStorage(storage, name, childState, txIDs, logger)
+}
+
+// IsReadOnly is true when the app can never modify its database
+func (app *App) IsReadOnly() bool {
+	return app.readOnly
+}
+
 // SetHeight updates the app's tendermint height
 //
 // Under normal circumstances, this should never be called by a child
@@ -284,6 +341,9 @@ func (app *App) UpdateState(updaters ...func(state metast.State) (metast.State,
 // This is buggy behavior and this function should NEVER be called in ordinary
 // usage. However, we've got a running blockchain for which playback depends on
 // replicating past bugs, so we have to be able to choose the old behavior.
+//
+// Prefer UpdateStateGated, which switches to the correct behavior at a
+// configured height.
 func (app *App) UpdateStateLeaky(updaters ...func(state metast.State) (metast.State, error)) error {
 	return app.updateStateInner(true, updaters...)
 }
@@ -297,24 +357,15 @@ func (app *App) updateStateInner(leak bool, updaters ...func(state metast.State)
 		// the state leaks backwards into the child state, even if an error
 		// is returned. This is highly undesirable behavior.
 		//
-		// The normal recommendation in this case is to manually cast the interface
-		// value into its concrete type, so that we can make a copy using normal
-		// semantics. Unfortunately, that's impossible in this case: we can't name
-		// the concrete type, because it depends on the particular app. Even if
-		// we were willing to manually enumerate all blockchains depending on the
-		// metaapp, we couldn't name those types, because it would lead to circular
-		// import paths.
-		//
-		// Therefore, we have to use reflection to force go to make a copy.
-
-		// this indirect captures the concrete type of the state object
-		indirect := reflect.Indirect(reflect.ValueOf(state))
-		// create a new instance of the concrete type
-		indirect2 := reflect.New(indirect.Type())
-		// set the value of the new indirect to the content of the old
-		indirect2.Elem().Set(reflect.ValueOf(indirect.Interface()))
-		// set the state variable to the copy
-		state = indirect2.Interface().(metast.State)
+		// A shallow copy of the concrete value isn't enough: maps and slices
+		// in the copy would still alias the original. We therefore make a deep
+		// copy, using the state's own Clone method if it implements
+		// metast.Cloner, and a noms round trip otherwise.
+		var err error
+		state, err = app.cloneChildState(state)
+		if err != nil {
+			return errors.Wrap(err, "UpdateState")
+		}
 	}
 
 	for _, updater := range updaters {
@@ -336,7 +387,12 @@ func (app *App) updateStateInner(leak bool, updaters ...func(state metast.State)
 // It also increments the height offset.
 //
 // This is useful for inserting mock data etc.
+//
+// Read-only apps return ErrReadOnly without updating the state.
 func (app *App) UpdateStateImmediately(updaters ...func(state metast.State) (metast.State, error)) error {
+	if app.readOnly {
+		return ErrReadOnly
+	}
 	err := app.UpdateState(updaters...)
 	if err != nil {
 		return err
@@ -356,6 +412,11 @@ func (app *App) Close() error {
 // However, they're related: think HARD before using this function
 // outside of func Commit.
 func (app *App) commit(logger log.FieldLogger) (err error) {
+	if app.readOnly {
+		err = ErrReadOnly
+		logger.WithError(err).Error("meta-application commit")
+		return err
+	}
 	ds, err := app.state.Commit(app.db, app.ds)
 	if err == nil {
 		app.ds = ds
@@ -369,11 +430,6 @@ func (app *App) Height() uint64 {
 	return app.height
 }
 
-// Validators returns a list of the app's validators.
-func (app *App) Validators() ([]abci.Validator, error) {
-	return app.state.GetValidators()
-}
-
 // Support for closing the app with Ctrl+C when running in a shell.
 type sigListener struct {
 	sigchan chan os.Signal
@@ -410,6 +466,54 @@ func (app *App) GetStats() metast.VoteStats {
 	return app.state.Stats
 }
 
+// SetVoteHistorySize sets the number of rounds retained in the vote statistics window
+//
+// This is intended to be called by child apps while applying a governance
+// transaction. The new size takes effect at the next BeginBlock.
+func (app *App) SetVoteHistorySize(size uint64) error {
+	if size == 0 {
+		return errors.New("vote history size must be positive")
+	}
+	app.state.SetVoteHistorySize(size)
+	return nil
+}
+
+// TrackVoteTotals begins accumulating per-validator vote totals.
+//
+// See metast.Metastate.TrackVoteTotals for when this should be called.
+func (app *App) TrackVoteTotals() {
+	app.state.TrackVoteTotals()
+}
+
+// GetVoteTotals returns the per-validator vote totals, keyed by base64 address.
+//
+// It returns nil unless TrackVoteTotals has been called. The totals are part
+// of the consensus state, so the returned map is a copy: changing it has no
+// effect on the app.
+func (app *App) GetVoteTotals() map[string]metast.VoteTotals {
+	totals := app.state.GetVoteTotals()
+	if totals == nil {
+		return nil
+	}
+	out := make(map[string]metast.VoteTotals, len(totals))
+	for addr, vt := range totals {
+		out[addr] = vt
+	}
+	return out
+}
+
+// SetTxLimits sets the bounds on serialized transactions accepted by CheckTx
+//
+// By default, metatx.DefaultLimits apply.
+func (app *App) SetTxLimits(limits metatx.Limits) {
+	app.txLimits = limits
+}
+
+// TxLimits returns the bounds on serialized transactions accepted by CheckTx
+func (app *App) TxLimits() metatx.Limits {
+	return app.txLimits
+}
+
 // BlockTime returns the timestamp of the current block
 //
 // Note that this can lag fairly significantly behind real time; the only upper
@@ -12,10 +12,12 @@ package app
 
 import (
 	"fmt"
+	"math/rand"
 	"testing"
 
 	nt "github.com/ndau/noms/go/types"
 	metast "github.com/ndau/metanode/pkg/meta/state"
+	"github.com/ndau/metanode/pkg/meta/state/statetest"
 	metatx "github.com/ndau/metanode/pkg/meta/transaction"
 	"github.com/pkg/errors"
 	"github.com/stretchr/testify/require"
@@ -85,3 +87,114 @@ func TestUpdateStateLeakyOperatesOnAReference(t *testing.T) {
 	s2 := app.GetState().(*TestState)
 	require.Equal(t, 2, s2.Value)
 }
+
+// MapState is a state whose content is a map, which a shallow copy would alias
+type MapState struct {
+	Values map[string]int
+}
+
+var _ metast.State = (*MapState)(nil)
+
+func (ms *MapState) Init(nt.ValueReadWriter) {
+	ms.Values = make(map[string]int)
+}
+
+func (ms MapState) MarshalNoms(vrw nt.ValueReadWriter) (nt.Value, error) {
+	kvs := make([]nt.Value, 0, 2*len(ms.Values))
+	for k, v := range ms.Values {
+		kvs = append(kvs, nt.String(k), nt.Number(v))
+	}
+	return nt.NewMap(vrw, kvs...), nil
+}
+
+func (ms *MapState) UnmarshalNoms(v nt.Value) (err error) {
+	m, ok := v.(nt.Map)
+	if !ok {
+		return fmt.Errorf("expected Map, got %T", v)
+	}
+	ms.Values = make(map[string]int)
+	m.IterAll(func(k, v nt.Value) {
+		ms.Values[string(k.(nt.String))] = int(v.(nt.Number))
+	})
+	return nil
+}
+
+func (ms *MapState) values() map[string]int {
+	return ms.Values
+}
+
+// CloningMapState implements metast.Cloner
+type CloningMapState struct {
+	MapState
+	clones int
+}
+
+var _ metast.Cloner = (*CloningMapState)(nil)
+
+func (cms *CloningMapState) Clone() metast.State {
+	cms.clones++
+	clone := CloningMapState{clones: cms.clones}
+	clone.Values = make(map[string]int, len(cms.Values))
+	for k, v := range cms.Values {
+		clone.Values[k] = v
+	}
+	return &clone
+}
+
+func TestUpdateStateMapMutationsDoNotLeak(t *testing.T) {
+	type mapper interface {
+		values() map[string]int
+	}
+
+	for _, child := range []metast.State{&MapState{}, &CloningMapState{}} {
+		t.Run(fmt.Sprintf("%T", child), func(t *testing.T) {
+			app, err := NewApp("mem", "test", child, metatx.TxIDMap{})
+			require.NoError(t, err)
+
+			err = app.UpdateState(func(stI metast.State) (metast.State, error) {
+				stI.(mapper).values()["a"] = 1
+				return stI, nil
+			})
+			require.NoError(t, err)
+
+			err = app.UpdateState(func(stI metast.State) (metast.State, error) {
+				m := stI.(mapper).values()
+				m["a"] = 2
+				m["b"] = 3
+				return stI, errors.New("must discard changes now")
+			})
+			require.Error(t, err)
+			require.Equal(t, map[string]int{"a": 1}, app.GetState().(mapper).values())
+
+			err = app.UpdateState(func(stI metast.State) (metast.State, error) {
+				delete(stI.(mapper).values(), "a")
+				return stI, errors.New("must discard changes now")
+			})
+			require.Error(t, err)
+			require.Equal(t, map[string]int{"a": 1}, app.GetState().(mapper).values())
+		})
+	}
+}
+
+func TestUpdateStateUsesCloner(t *testing.T) {
+	app, err := NewApp("mem", "test", &CloningMapState{}, metatx.TxIDMap{})
+	require.NoError(t, err)
+
+	for i := 1; i <= 3; i++ {
+		err = app.UpdateState(func(stI metast.State) (metast.State, error) {
+			return stI, nil
+		})
+		require.NoError(t, err)
+		require.Equal(t, i, app.GetState().(*CloningMapState).clones)
+	}
+}
+
+func TestMapStateConformance(t *testing.T) {
+	statetest.Run(t, func(r *rand.Rand) metast.State {
+		ms := MapState{Values: make(map[string]int)}
+		for i := r.Intn(20); i > 0; i-- {
+			ms.Values[fmt.Sprintf("k%d", r.Intn(100))] = r.Intn(1 << 20)
+		}
+		return &ms
+	}, statetest.Options{Iterations: 20})
+}
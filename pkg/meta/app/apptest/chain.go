@@ -0,0 +1,287 @@
+// ----- ---- --- -- -
+// Copyright 2019, 2020 The Axiom Foundation. All Rights Reserved.
+//
+// Licensed under the Apache License 2.0 (the "License").  You may not use
+// this file except in compliance with the License.  You can obtain a copy
+// in the file LICENSE in the source distribution or at
+// https://www.apache.org/licenses/LICENSE-2.0.txt
+// - -- --- ---- -----
+
+// Package apptest drives meta.App children through simulated blocks.
+//
+// Everything about a simulated block is under the test's control: block
+// times advance by a fixed interval from a fixed genesis time, validator votes
+// and byzantine evidence are injected explicitly, and every transaction is
+// submitted with the return code it is expected to produce. The same test
+// therefore produces the same chain every time it runs.
+package apptest
+
+import (
+	"testing"
+	"time"
+
+	meta "github.com/ndau/metanode/pkg/meta/app"
+	"github.com/ndau/metanode/pkg/meta/app/code"
+	"github.com/ndau/metanode/pkg/meta/app/internal/snapshot"
+	metatx "github.com/ndau/metanode/pkg/meta/transaction"
+	"github.com/stretchr/testify/require"
+	abci "github.com/tendermint/tendermint/abci/types"
+)
+
+// GenesisTime is the default time of the first simulated block
+var GenesisTime = time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// DefaultBlockInterval is the default time between simulated blocks
+const DefaultBlockInterval = time.Second
+
+// Tx is a transaction to submit, and the return code it must produce
+type Tx struct {
+	// Transactable is serialized with the chain's TxIDs, unless Bytes is set
+	Transactable metatx.Transactable
+	// Bytes are submitted verbatim when set
+	Bytes []byte
+	// Code is the expected return code
+	Code code.ReturnCode
+}
+
+// Expect returns a Tx which must produce the given return code
+func Expect(rc code.ReturnCode, txab metatx.Transactable) Tx {
+	return Tx{Transactable: txab, Code: rc}
+}
+
+// BlockResult collects the app's responses to a simulated block
+type BlockResult struct {
+	BeginBlock abci.ResponseBeginBlock
+	DeliverTx  []abci.ResponseDeliverTx
+	EndBlock   abci.ResponseEndBlock
+	Commit     abci.ResponseCommit
+}
+
+// Chain drives an App through simulated blocks
+//
+// Failed expectations fail the test immediately.
+type Chain struct {
+	t     testing.TB
+	app   *meta.App
+	txIDs metatx.TxIDResolver
+
+	height   uint64
+	time     time.Time
+	interval time.Duration
+	votes    []abci.VoteInfo
+	evidence []abci.Evidence
+}
+
+// NewChain creates a Chain driving the given app
+//
+// The app is the meta.App embedded in the child application. Transactables
+// are serialized with txIDs, which should be those the app was created with.
+//
+// The first block is at the height following the app's current height.
+func NewChain(t testing.TB, app *meta.App, txIDs metatx.TxIDResolver) *Chain {
+	return &Chain{
+		t:        t,
+		app:      app,
+		txIDs:    txIDs,
+		height:   app.Height() + 1,
+		time:     GenesisTime,
+		interval: DefaultBlockInterval,
+	}
+}
+
+// App returns the app driven by this chain
+func (c *Chain) App() *meta.App {
+	return c.app
+}
+
+// NextHeight returns the height of the next block
+func (c *Chain) NextHeight() uint64 {
+	return c.height
+}
+
+// SetNextHeight sets the height of the next block
+func (c *Chain) SetNextHeight(height uint64) {
+	c.height = height
+}
+
+// NextTime returns the time of the next block
+func (c *Chain) NextTime() time.Time {
+	return c.time
+}
+
+// SetNextTime sets the time of the next block
+//
+// Subsequent blocks follow it at the block interval.
+func (c *Chain) SetNextTime(t time.Time) {
+	c.time = t
+}
+
+// SetBlockInterval sets the time between blocks
+func (c *Chain) SetBlockInterval(interval time.Duration) {
+	c.interval = interval
+}
+
+// SetVotes sets the validator votes included in every subsequent block
+func (c *Chain) SetVotes(votes ...abci.VoteInfo) {
+	c.votes = votes
+}
+
+// Vote constructs the vote info of a validator
+func Vote(address []byte, power int64, signed bool) abci.VoteInfo {
+	return abci.VoteInfo{
+		Validator:       abci.Validator{Address: address, Power: power},
+		SignedLastBlock: signed,
+	}
+}
+
+// InjectEvidence adds byzantine evidence to the next block only
+func (c *Chain) InjectEvidence(evidence ...abci.Evidence) {
+	c.evidence = append(c.evidence, evidence...)
+}
+
+// DuplicateVote constructs evidence that a validator voted twice at a height
+func DuplicateVote(address []byte, power int64, height uint64) abci.Evidence {
+	return abci.Evidence{
+		Type:      "duplicate/vote",
+		Validator: abci.Validator{Address: address, Power: power},
+		Height:    int64(height),
+	}
+}
+
+func (c *Chain) bytes(tx Tx) []byte {
+	c.t.Helper()
+	if tx.Bytes != nil {
+		return tx.Bytes
+	}
+	bytes, err := metatx.MarshalWithResolver(tx.Transactable, c.txIDs)
+	require.NoError(c.t, err)
+	return bytes
+}
+
+// CheckTx submits a transaction to the mempool and requires the expected code
+func (c *Chain) CheckTx(tx Tx) abci.ResponseCheckTx {
+	c.t.Helper()
+	resp := c.app.CheckTx(abci.RequestCheckTx{Tx: c.bytes(tx)})
+	require.Equal(c.t, tx.Code, code.ReturnCode(resp.Code), resp.Log)
+	return resp
+}
+
+// Block issues a block in which every transaction must succeed
+func (c *Chain) Block(txabs ...metatx.Transactable) BlockResult {
+	c.t.Helper()
+	txs := make([]Tx, 0, len(txabs))
+	for _, txab := range txabs {
+		txs = append(txs, Expect(code.OK, txab))
+	}
+	return c.BlockWith(txs...)
+}
+
+// BlockWith issues a block in which every transaction must produce its
+// expected code
+func (c *Chain) BlockWith(txs ...Tx) (result BlockResult) {
+	c.t.Helper()
+	result.BeginBlock = c.app.BeginBlock(abci.RequestBeginBlock{
+		Header: abci.Header{
+			Height: int64(c.height),
+			Time:   c.time,
+		},
+		LastCommitInfo: abci.LastCommitInfo{
+			Votes: c.votes,
+		},
+		ByzantineValidators: c.evidence,
+	})
+	c.evidence = nil
+
+	for i, tx := range txs {
+		resp := c.app.DeliverTx(abci.RequestDeliverTx{Tx: c.bytes(tx)})
+		require.Equal(c.t, tx.Code, code.ReturnCode(resp.Code), "tx %d at height %d: %s", i, c.height, resp.Log)
+		result.DeliverTx = append(result.DeliverTx, resp)
+	}
+
+	result.EndBlock = c.app.EndBlock(abci.RequestEndBlock{Height: int64(c.height)})
+	result.Commit = c.app.Commit()
+	require.Equal(c.t, c.height, c.app.Height())
+
+	c.height++
+	c.time = c.time.Add(c.interval)
+	return
+}
+
+// Blocks issues n empty blocks
+func (c *Chain) Blocks(n int) {
+	c.t.Helper()
+	for i := 0; i < n; i++ {
+		c.Block()
+	}
+}
+
+// AppSnapshot is a point-in-time copy of an App
+//
+// Restoring a snapshot rewinds the app, including the head of its dataset,
+// to the moment the snapshot was taken. This lets tests share an expensive
+// setup between cases. Rewinding history is never legitimate outside tests,
+// so snapshots are only available through this package.
+//
+// Nothing is ever deleted from a noms database, so a snapshot remains valid
+// however far the app advances after it is taken. It can only be restored
+// into the app which took it.
+type AppSnapshot struct {
+	snapshot *snapshot.Snapshot
+}
+
+// SnapshotApp takes a snapshot of an app
+func SnapshotApp(app *meta.App) (*AppSnapshot, error) {
+	s, err := snapshot.Take(app)
+	if err != nil {
+		return nil, err
+	}
+	return &AppSnapshot{snapshot: s}, nil
+}
+
+// RestoreApp rewinds an app to a snapshot which it took
+//
+// Read-only apps can't be rewound.
+func RestoreApp(app *meta.App, s *AppSnapshot) error {
+	if s == nil {
+		return snapshot.Restore(app, nil)
+	}
+	return snapshot.Restore(app, s.snapshot)
+}
+
+// Snapshot is a point-in-time copy of a Chain and its app
+type Snapshot struct {
+	app      *AppSnapshot
+	height   uint64
+	time     time.Time
+	interval time.Duration
+	votes    []abci.VoteInfo
+	evidence []abci.Evidence
+}
+
+// Snapshot takes a snapshot of the chain
+func (c *Chain) Snapshot() *Snapshot {
+	c.t.Helper()
+	appSnapshot, err := SnapshotApp(c.app)
+	require.NoError(c.t, err)
+	return &Snapshot{
+		app:      appSnapshot,
+		height:   c.height,
+		time:     c.time,
+		interval: c.interval,
+		votes:    append([]abci.VoteInfo(nil), c.votes...),
+		evidence: append([]abci.Evidence(nil), c.evidence...),
+	}
+}
+
+// Restore rewinds the chain and its app to a snapshot
+//
+// A snapshot may be restored any number of times.
+func (c *Chain) Restore(snapshot *Snapshot) {
+	c.t.Helper()
+	require.NoError(c.t, RestoreApp(c.app, snapshot.app))
+	c.height = snapshot.height
+	c.time = snapshot.time
+	c.interval = snapshot.interval
+	c.votes = append([]abci.VoteInfo(nil), snapshot.votes...)
+	c.evidence = append([]abci.Evidence(nil), snapshot.evidence...)
+}
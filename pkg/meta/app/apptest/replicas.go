@@ -0,0 +1,186 @@
+package apptest
+
+// ----- ---- --- -- -
+// Copyright 2019, 2020 The Axiom Foundation. All Rights Reserved.
+//
+// Licensed under the Apache License 2.0 (the "License").  You may not use
+// this file except in compliance with the License.  You can obtain a copy
+// in the file LICENSE in the source distribution or at
+// https://www.apache.org/licenses/LICENSE-2.0.txt
+// - -- --- ---- -----
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+	"time"
+
+	meta "github.com/ndau/metanode/pkg/meta/app"
+	"github.com/ndau/metanode/pkg/meta/app/code"
+	metatx "github.com/ndau/metanode/pkg/meta/transaction"
+	"github.com/ndau/noms/go/diff"
+	"github.com/stretchr/testify/require"
+	abci "github.com/tendermint/tendermint/abci/types"
+)
+
+// Replicas drives several apps with an identical block stream, as the
+// nodes of a network would be
+//
+// After every block, the app hashes of the replicas must agree. A
+// disagreement means that some part of the app is nondeterministic: it
+// iterates over a map, reads the clock, or uses randomness.
+type Replicas struct {
+	t      testing.TB
+	txIDs  metatx.TxIDResolver
+	chains []*Chain
+}
+
+// NewReplicas creates Replicas driving the given apps
+//
+// The apps must be freshly constructed in the same way, and must all be at
+// the same height.
+func NewReplicas(t testing.TB, txIDs metatx.TxIDResolver, apps ...*meta.App) *Replicas {
+	require.NotEmpty(t, apps, "NewReplicas requires at least one app")
+	r := Replicas{t: t, txIDs: txIDs}
+	for _, app := range apps {
+		require.Equal(t, apps[0].Height(), app.Height(), "replicas must start at the same height")
+		r.chains = append(r.chains, NewChain(t, app, txIDs))
+	}
+	return &r
+}
+
+// Chains returns the chains driving each replica
+func (r *Replicas) Chains() []*Chain {
+	return r.chains
+}
+
+// SetNextHeight sets the height of the next block
+func (r *Replicas) SetNextHeight(height uint64) {
+	for _, c := range r.chains {
+		c.SetNextHeight(height)
+	}
+}
+
+// SetNextTime sets the time of the next block
+func (r *Replicas) SetNextTime(t time.Time) {
+	for _, c := range r.chains {
+		c.SetNextTime(t)
+	}
+}
+
+// SetBlockInterval sets the time between blocks
+func (r *Replicas) SetBlockInterval(interval time.Duration) {
+	for _, c := range r.chains {
+		c.SetBlockInterval(interval)
+	}
+}
+
+// SetVotes sets the validator votes included in every subsequent block
+func (r *Replicas) SetVotes(votes ...abci.VoteInfo) {
+	for _, c := range r.chains {
+		c.SetVotes(votes...)
+	}
+}
+
+// InjectEvidence adds byzantine evidence to the next block only
+func (r *Replicas) InjectEvidence(evidence ...abci.Evidence) {
+	for _, c := range r.chains {
+		c.InjectEvidence(evidence...)
+	}
+}
+
+// Block issues a block to every replica in which every transaction must
+// succeed, then requires that the replicas agree
+func (r *Replicas) Block(txabs ...metatx.Transactable) []BlockResult {
+	r.t.Helper()
+	txs := make([]Tx, 0, len(txabs))
+	for _, txab := range txabs {
+		txs = append(txs, Expect(code.OK, txab))
+	}
+	return r.BlockWith(txs...)
+}
+
+// BlockWith issues a block to every replica in which every transaction
+// must produce its expected code, then requires that the replicas agree
+func (r *Replicas) BlockWith(txs ...Tx) []BlockResult {
+	r.t.Helper()
+	// serialize each tx once: every serialization has a fresh nonce
+	for i := range txs {
+		if txs[i].Bytes == nil {
+			txBytes, err := metatx.MarshalWithResolver(txs[i].Transactable, r.txIDs)
+			require.NoError(r.t, err)
+			txs[i].Bytes = txBytes
+		}
+	}
+
+	results := make([]BlockResult, 0, len(r.chains))
+	for _, c := range r.chains {
+		results = append(results, c.BlockWith(txs...))
+	}
+	require.NoError(r.t, r.Compare())
+	return results
+}
+
+// Blocks issues n empty blocks to every replica
+func (r *Replicas) Blocks(n int) {
+	r.t.Helper()
+	for i := 0; i < n; i++ {
+		r.Block()
+	}
+}
+
+// DivergenceError describes a disagreement between replicas
+type DivergenceError struct {
+	Height  uint64
+	Replica int
+	Hashes  [2]string
+	// Diff shows the differing subtrees of the replicas' committed metastates
+	Diff string
+}
+
+func (e DivergenceError) Error() string {
+	return fmt.Sprintf(
+		"replica %d diverged from replica 0 at height %d: app hash %s != %s\n%s",
+		e.Replica, e.Height, e.Hashes[1], e.Hashes[0], e.Diff,
+	)
+}
+
+// Compare returns a DivergenceError if any replica's app hash differs from
+// that of the first
+func (r *Replicas) Compare() error {
+	first := r.chains[0].App()
+	for i, c := range r.chains[1:] {
+		app := c.App()
+		if bytes.Equal(first.Hash(), app.Hash()) {
+			continue
+		}
+		return DivergenceError{
+			Height:  app.Height(),
+			Replica: i + 1,
+			Hashes:  [2]string{first.HashStr(), app.HashStr()},
+			Diff:    diffHeads(first, app),
+		}
+	}
+	return nil
+}
+
+// diffHeads describes the differences between the committed metastates of
+// two apps
+func diffHeads(a, b *meta.App) string {
+	va, aok := a.GetDS().MaybeHeadValue()
+	vb, bok := b.GetDS().MaybeHeadValue()
+	switch {
+	case !aok && !bok:
+		return "neither replica has committed a state"
+	case !aok:
+		return "replica 0 has not committed a state"
+	case !bok:
+		return "the diverging replica has not committed a state"
+	}
+	buf := new(bytes.Buffer)
+	err := diff.PrintDiff(buf, va, vb, false)
+	if err != nil {
+		fmt.Fprintf(buf, "\n(diff failed: %s)", err)
+	}
+	return buf.String()
+}
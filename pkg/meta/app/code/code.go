@@ -25,4 +25,7 @@ const (
 	QueryError
 	IndexingError
 	InvalidNodeState
+	DeprecatedTransaction
+	TransactionNotYetValid
+	TransactionLimitExceeded
 )
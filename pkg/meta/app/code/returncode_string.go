@@ -25,11 +25,14 @@ func _() {
 	_ = x[QueryError-4]
 	_ = x[IndexingError-5]
 	_ = x[InvalidNodeState-6]
+	_ = x[DeprecatedTransaction-7]
+	_ = x[TransactionNotYetValid-8]
+	_ = x[TransactionLimitExceeded-9]
 }
 
-const _ReturnCode_name = "OKInvalidTransactionErrorApplyingTransactionEncodingErrorQueryErrorIndexingErrorInvalidNodeState"
+const _ReturnCode_name = "OKInvalidTransactionErrorApplyingTransactionEncodingErrorQueryErrorIndexingErrorInvalidNodeStateDeprecatedTransactionTransactionNotYetValidTransactionLimitExceeded"
 
-var _ReturnCode_index = [...]uint8{0, 2, 20, 44, 57, 67, 80, 96}
+var _ReturnCode_index = [...]uint8{0, 2, 20, 44, 57, 67, 80, 96, 117, 139, 163}
 
 func (i ReturnCode) String() string {
 	if i >= ReturnCode(len(_ReturnCode_index)-1) {
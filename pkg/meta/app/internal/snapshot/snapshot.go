@@ -0,0 +1,42 @@
+// ----- ---- --- -- -
+// Copyright 2019, 2020 The Axiom Foundation. All Rights Reserved.
+//
+// Licensed under the Apache License 2.0 (the "License").  You may not use
+// this file except in compliance with the License.  You can obtain a copy
+// in the file LICENSE in the source distribution or at
+// https://www.apache.org/licenses/LICENSE-2.0.txt
+// - -- --- ---- -----
+
+// Package snapshot connects apptest to the App's snapshot implementation.
+//
+// Restoring a snapshot rewinds an App's history, which production code must
+// never do. The App therefore has no exported snapshot methods: package app
+// sets these hooks during initialization, and, as this package is internal,
+// only apptest and the app's own tests can call them.
+package snapshot
+
+import (
+	math "github.com/ndau/ndaumath/pkg/types"
+	nt "github.com/ndau/noms/go/types"
+	abci "github.com/tendermint/tendermint/abci/types"
+)
+
+// Snapshot is a point-in-time copy of an App
+type Snapshot struct {
+	// App is the *meta.App which took the snapshot
+	App                 interface{}
+	Head                nt.Ref
+	HasHead             bool
+	State               nt.Value
+	Height              uint64
+	BlockTime           math.Timestamp
+	TransactionsPending uint64
+	ValUpdates          []abci.ValidatorUpdate
+}
+
+var (
+	// Take takes a snapshot of a *meta.App
+	Take func(app interface{}) (*Snapshot, error)
+	// Restore rewinds a *meta.App to a snapshot which it took
+	Restore func(app interface{}, snapshot *Snapshot) error
+)
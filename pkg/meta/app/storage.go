@@ -0,0 +1,214 @@
+package app
+
+// ----- ---- --- -- -
+// Copyright 2019, 2020 The Axiom Foundation. All Rights Reserved.
+//
+// Licensed under the Apache License 2.0 (the "License").  You may not use
+// this file except in compliance with the License.  You can obtain a copy
+// in the file LICENSE in the source distribution or at
+// https://www.apache.org/licenses/LICENSE-2.0.txt
+// - -- --- ---- -----
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/ndau/noms/go/d"
+	"github.com/ndau/noms/go/datas"
+	"github.com/ndau/noms/go/nbs"
+	"github.com/ndau/noms/go/spec"
+	nt "github.com/ndau/noms/go/types"
+	"github.com/pkg/errors"
+)
+
+// StorageBackend is a kind of noms database
+type StorageBackend string
+
+// These are the supported storage backends
+const (
+	// MemoryBackend stores everything in memory. Nothing outlives the App.
+	MemoryBackend StorageBackend = "mem"
+	// NBSBackend stores the database in a local directory
+	NBSBackend StorageBackend = "nbs"
+	// HTTPBackend connects to a noms server
+	HTTPBackend StorageBackend = "http"
+)
+
+// DefaultMemTableSize is the default size in bytes of the in-memory table in
+// which an NBS database buffers writes
+const DefaultMemTableSize = 128 * 1024 * 1024
+
+// ErrReadOnly is returned when a read-only App is asked to write
+var ErrReadOnly = errors.New("the app's storage is read-only")
+
+// datasetRe matches valid noms dataset names
+var datasetRe = regexp.MustCompile(`^[a-zA-Z0-9\-_/]+$`)
+
+// StorageConfig configures the database in which an App stores its state
+type StorageConfig struct {
+	Backend StorageBackend
+	// Path is the directory of an NBS database, or the URL of a noms server.
+	// It must be empty for the memory backend.
+	Path string
+	// Dataset is the name of the dataset holding the state.
+	// If empty, the App's name is used.
+	Dataset string
+	// MemTableSize is the size in bytes of the NBS write buffer.
+	// 0 means DefaultMemTableSize.
+	MemTableSize uint64
+	// ReadOnly opens an existing database without ever writing to it.
+	// The opened database rejects every operation which would move the head
+	// of a dataset with ErrReadOnly.
+	ReadOnly bool
+}
+
+// MemoryStorage returns the configuration of an in-memory database
+func MemoryStorage() StorageConfig {
+	return StorageConfig{Backend: MemoryBackend}
+}
+
+// ParseStorageSpec converts a noms database spec string into a StorageConfig
+//
+// Empty specs and "mem" are in-memory databases, "http://" and "https://"
+// URLs are noms servers, and anything else is the path of an NBS database,
+// optionally prefixed by "nbs:".
+func ParseStorageSpec(dbSpec string) (StorageConfig, error) {
+	switch {
+	case strings.Contains(dbSpec, "::"):
+		return StorageConfig{}, fmt.Errorf("%s: database specs may not name a dataset", dbSpec)
+	case dbSpec == "" || dbSpec == "mem":
+		return MemoryStorage(), nil
+	case strings.HasPrefix(dbSpec, "http://") || strings.HasPrefix(dbSpec, "https://"):
+		return StorageConfig{Backend: HTTPBackend, Path: dbSpec}, nil
+	case strings.HasPrefix(dbSpec, "nbs:"):
+		return StorageConfig{Backend: NBSBackend, Path: strings.TrimPrefix(dbSpec, "nbs:")}, nil
+	default:
+		return StorageConfig{Backend: NBSBackend, Path: dbSpec}, nil
+	}
+}
+
+// Validate returns an error if the configuration is unusable
+func (c StorageConfig) Validate() error {
+	switch c.Backend {
+	case MemoryBackend:
+		if c.Path != "" {
+			return errors.New("storage: the memory backend has no path")
+		}
+		if c.ReadOnly {
+			return errors.New("storage: a read-only memory database would always be empty")
+		}
+	case NBSBackend:
+		if c.Path == "" {
+			return errors.New("storage: the nbs backend requires a path")
+		}
+	case HTTPBackend:
+		if !strings.HasPrefix(c.Path, "http://") && !strings.HasPrefix(c.Path, "https://") {
+			return fmt.Errorf("storage: %q is not an http or https URL", c.Path)
+		}
+	default:
+		return fmt.Errorf("storage: unknown backend %q", c.Backend)
+	}
+	if c.MemTableSize != 0 && c.Backend != NBSBackend {
+		return errors.New("storage: only the nbs backend has a mem table")
+	}
+	if c.Dataset != "" && !datasetRe.MatchString(c.Dataset) {
+		return fmt.Errorf("storage: invalid dataset name %q", c.Dataset)
+	}
+	return nil
+}
+
+// Open connects to the configured database
+//
+// Apps open their storage themselves; this is for tools which work with the
+// database directly.
+func (c StorageConfig) Open() (db datas.Database, err error) {
+	err = c.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	switch c.Backend {
+	case NBSBackend:
+		if c.ReadOnly {
+			_, err = os.Stat(c.Path)
+		} else {
+			err = os.MkdirAll(c.Path, 0700)
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, "storage: nbs directory")
+		}
+		memTableSize := c.MemTableSize
+		if memTableSize == 0 {
+			memTableSize = DefaultMemTableSize
+		}
+		// we use Try() because noms panics in various places
+		err = d.Try(func() {
+			db = datas.NewDatabase(nbs.NewLocalStore(c.Path, memTableSize))
+		})
+	default:
+		var sp spec.Spec
+		sp, err = spec.ForDatabase(c.spec())
+		if err != nil {
+			return nil, errors.Wrap(err, "storage: invalid database spec")
+		}
+		err = d.Try(func() {
+			db = sp.GetDatabase()
+		})
+	}
+	if err != nil {
+		return nil, errors.Wrapf(d.Unwrap(err), "storage: failed to connect to noms db %s", c.spec())
+	}
+	if c.ReadOnly {
+		db = readOnlyDatabase{db}
+	}
+	return db, nil
+}
+
+// readOnlyDatabase wraps a database, rejecting every operation which would
+// move the head of a dataset
+//
+// noms opens NBS stores with write access regardless, so this is what keeps
+// holders of a read-only App's database, via GetDB, from modifying it.
+// Values written without being committed are unreachable.
+type readOnlyDatabase struct {
+	datas.Database
+}
+
+// Commit implements datas.Database
+func (readOnlyDatabase) Commit(ds datas.Dataset, v nt.Value, opts datas.CommitOptions) (datas.Dataset, error) {
+	return ds, ErrReadOnly
+}
+
+// CommitValue implements datas.Database
+func (readOnlyDatabase) CommitValue(ds datas.Dataset, v nt.Value) (datas.Dataset, error) {
+	return ds, ErrReadOnly
+}
+
+// Delete implements datas.Database
+func (readOnlyDatabase) Delete(ds datas.Dataset) (datas.Dataset, error) {
+	return ds, ErrReadOnly
+}
+
+// SetHead implements datas.Database
+func (readOnlyDatabase) SetHead(ds datas.Dataset, newHeadRef nt.Ref) (datas.Dataset, error) {
+	return ds, ErrReadOnly
+}
+
+// FastForward implements datas.Database
+func (readOnlyDatabase) FastForward(ds datas.Dataset, newHeadRef nt.Ref) (datas.Dataset, error) {
+	return ds, ErrReadOnly
+}
+
+// spec returns the noms spec string of the configured database
+func (c StorageConfig) spec() string {
+	switch c.Backend {
+	case MemoryBackend:
+		return "mem"
+	case NBSBackend:
+		return "nbs:" + c.Path
+	default:
+		return c.Path
+	}
+}
@@ -15,6 +15,7 @@ import (
 
 	meta "github.com/ndau/metanode/pkg/meta/app"
 	metast "github.com/ndau/metanode/pkg/meta/state"
+	metatx "github.com/ndau/metanode/pkg/meta/transaction"
 	util "github.com/ndau/noms-util"
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
@@ -25,11 +26,29 @@ type TestApp struct {
 	*meta.App
 }
 
+const appName = "TestApp"
+
 // NewTestApp constructs a new TestApp
 func NewTestApp() (*TestApp, error) {
-	dbSpec := "mem"
-	name := "TestApp"
-	metaapp, err := meta.NewApp(dbSpec, name, &TestState{}, TxIDs)
+	return NewTestAppWithTxIDs(TxIDs)
+}
+
+// NewTestAppWithTxIDs constructs a new TestApp which recognizes the given transactions
+func NewTestAppWithTxIDs(txIDs metatx.TxIDResolver) (*TestApp, error) {
+	return newTestApp(meta.NewAppWithStorage(meta.MemoryStorage(), appName, &TestState{}, txIDs, nil))
+}
+
+// NewTestAppWithStorage constructs a new TestApp whose state is stored as configured
+func NewTestAppWithStorage(storage meta.StorageConfig) (*TestApp, error) {
+	return newTestApp(meta.NewAppWithStorage(storage, appName, &TestState{}, TxIDs, nil))
+}
+
+// NewReadOnlyTestApp constructs a new TestApp which can't modify its storage
+func NewReadOnlyTestApp(storage meta.StorageConfig) (*TestApp, error) {
+	return newTestApp(meta.NewReadOnlyApp(storage, appName, &TestState{}, TxIDs, nil))
+}
+
+func newTestApp(metaapp *meta.App, err error) (*TestApp, error) {
 	if err != nil {
 		return nil, errors.Wrap(err, "NewApp failed to create metaapp")
 	}
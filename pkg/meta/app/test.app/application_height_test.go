@@ -13,30 +13,12 @@ package testapp
 import (
 	"math/rand"
 	"testing"
-	"time"
 
-	"github.com/ndau/metanode/pkg/meta/app/code"
-	metatx "github.com/ndau/metanode/pkg/meta/transaction"
+	"github.com/ndau/metanode/pkg/meta/app/apptest"
 	"github.com/stretchr/testify/require"
 	abci "github.com/tendermint/tendermint/abci/types"
 )
 
-func issueBlock(t *testing.T, app *TestApp, height uint64, txs ...metatx.Transactable) {
-	app.BeginBlock(abci.RequestBeginBlock{Header: abci.Header{
-		Height: int64(height),
-		Time:   time.Now(),
-	}})
-	for _, tx := range txs {
-		bytes, err := metatx.Marshal(tx, TxIDs)
-		require.NoError(t, err)
-		resp := app.DeliverTx(abci.RequestDeliverTx{Tx: bytes})
-		t.Log(resp.Log)
-		require.Equal(t, code.OK, code.ReturnCode(resp.Code))
-	}
-	app.EndBlock(abci.RequestEndBlock{})
-	app.Commit()
-}
-
 // Creating noms blocks only when there are transactions is all well
 // and good, but we need to ensure that the app height is always what
 // tendermint expects.
@@ -50,8 +32,9 @@ func TestAppHeightFollowsTendermint(t *testing.T) {
 
 	apphash := app.Hash()
 
+	chain := apptest.NewChain(t, app.App, TxIDs)
 	for i := uint64(2); i < 6; i++ {
-		issueBlock(t, app, i)
+		chain.Block()
 		require.Equal(t, i, app.Height())
 	}
 
@@ -71,13 +54,15 @@ func TestAppHeightAndHashUpdatePerTM(t *testing.T) {
 	require.Equal(t, uint64(1), app.Height())
 
 	tmBlock := uint64(0)
+	chain := apptest.NewChain(t, app.App, TxIDs)
+	chain.SetNextHeight(tmBlock)
 	outerLimit := int(rand.Int31n(10))
 	for outer := 0; outer <= outerLimit; outer++ {
 		apphash := app.Hash()
 
 		innerLimit := int(rand.Int31n(10))
 		for inner := 1; inner <= innerLimit; inner++ {
-			issueBlock(t, app, tmBlock)
+			chain.Block()
 			require.Equal(t, tmBlock, app.Height())
 			tmBlock++
 		}
@@ -87,7 +72,7 @@ func TestAppHeightAndHashUpdatePerTM(t *testing.T) {
 		require.Equal(t, apphash, app.Hash())
 
 		// now issue a non-empty block
-		issueBlock(t, app, tmBlock, &Add{1})
+		chain.Block(&Add{1})
 		require.Equal(t, tmBlock, app.Height())
 		tmBlock++
 
@@ -0,0 +1,110 @@
+package testapp
+
+// ----- ---- --- -- -
+// Copyright 2019, 2020 The Axiom Foundation. All Rights Reserved.
+//
+// Licensed under the Apache License 2.0 (the "License").  You may not use
+// this file except in compliance with the License.  You can obtain a copy
+// in the file LICENSE in the source distribution or at
+// https://www.apache.org/licenses/LICENSE-2.0.txt
+// - -- --- ---- -----
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ndau/metanode/pkg/meta/app/apptest"
+	"github.com/ndau/metanode/pkg/meta/app/code"
+	math "github.com/ndau/ndaumath/pkg/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChainBlockTimesAreDeterministic(t *testing.T) {
+	app, err := NewTestApp()
+	require.NoError(t, err)
+	chain := apptest.NewChain(t, app.App, TxIDs)
+	chain.SetBlockInterval(time.Minute)
+
+	chain.Blocks(3)
+	expect, err := math.TimestampFrom(apptest.GenesisTime.Add(2 * time.Minute))
+	require.NoError(t, err)
+	require.Equal(t, expect, app.BlockTime())
+	require.Equal(t, uint64(3), app.Height())
+	require.Equal(t, uint64(4), chain.NextHeight())
+}
+
+func TestChainExpectsReturnCodes(t *testing.T) {
+	app, err := NewTestApp()
+	require.NoError(t, err)
+	chain := apptest.NewChain(t, app.App, TxIDs)
+
+	result := chain.BlockWith(
+		apptest.Expect(code.OK, &Add{Qty: 2}),
+		apptest.Expect(code.InvalidTransaction, &Add{Qty: -1}),
+		apptest.Tx{Bytes: []byte{0xc1}, Code: code.EncodingError},
+	)
+	require.Len(t, result.DeliverTx, 3)
+	require.Equal(t, uint64(2), app.GetCount())
+
+	chain.CheckTx(apptest.Expect(code.InvalidTransaction, &SetHistorySize{}))
+}
+
+func TestChainInjectsEvidence(t *testing.T) {
+	app, err := NewTestApp()
+	require.NoError(t, err)
+	app.TrackEvidence()
+	chain := apptest.NewChain(t, app.App, TxIDs)
+
+	chain.InjectEvidence(apptest.DuplicateVote(voterAddress, 10, 1))
+	chain.Blocks(2)
+
+	// evidence is presented in one block only
+	evidence := app.GetEvidence()
+	require.Len(t, evidence, 1)
+	require.Equal(t, uint64(1), evidence[0].ReportedHeight)
+}
+
+func TestChainSnapshotRestore(t *testing.T) {
+	app, err := NewTestApp()
+	require.NoError(t, err)
+	chain := apptest.NewChain(t, app.App, TxIDs)
+
+	chain.Block(&Add{Qty: 1})
+	snapshot := chain.Snapshot()
+	hash := app.Hash()
+
+	for i := 0; i < 2; i++ {
+		chain.Block(&Add{Qty: 10})
+		chain.Blocks(3)
+		require.Equal(t, uint64(11), app.GetCount())
+		require.Equal(t, uint64(5), app.Height())
+		require.NotEqual(t, hash, app.Hash())
+
+		chain.Restore(snapshot)
+		require.Equal(t, uint64(1), app.GetCount())
+		require.Equal(t, uint64(1), app.Height())
+		require.Equal(t, hash, app.Hash())
+		require.Equal(t, uint64(2), chain.NextHeight())
+	}
+}
+
+func TestRestoreOfEmptyDataset(t *testing.T) {
+	app, err := NewTestApp()
+	require.NoError(t, err)
+	chain := apptest.NewChain(t, app.App, TxIDs)
+
+	snapshot := chain.Snapshot()
+	chain.Block(&Add{Qty: 1})
+	require.NotEmpty(t, app.Hash())
+
+	chain.Restore(snapshot)
+	require.Empty(t, app.Hash())
+	require.Equal(t, uint64(0), app.GetCount())
+
+	// restoring into a different app is an error
+	other, err := NewTestApp()
+	require.NoError(t, err)
+	snap, err := apptest.SnapshotApp(app.App)
+	require.NoError(t, err)
+	require.Error(t, apptest.RestoreApp(other.App, snap))
+}
@@ -0,0 +1,125 @@
+package testapp
+
+// ----- ---- --- -- -
+// Copyright 2019, 2020 The Axiom Foundation. All Rights Reserved.
+//
+// Licensed under the Apache License 2.0 (the "License").  You may not use
+// this file except in compliance with the License.  You can obtain a copy
+// in the file LICENSE in the source distribution or at
+// https://www.apache.org/licenses/LICENSE-2.0.txt
+// - -- --- ---- -----
+
+import (
+	"testing"
+	"time"
+
+	meta "github.com/ndau/metanode/pkg/meta/app"
+	"github.com/ndau/metanode/pkg/meta/app/code"
+	metast "github.com/ndau/metanode/pkg/meta/state"
+	"github.com/stretchr/testify/require"
+	abci "github.com/tendermint/tendermint/abci/types"
+)
+
+func genesisParams() *abci.ConsensusParams {
+	return &abci.ConsensusParams{
+		Block: &abci.BlockParams{
+			MaxBytes: 22020096,
+			MaxGas:   -1,
+		},
+		Evidence: &abci.EvidenceParams{
+			MaxAgeNumBlocks: 100000,
+			MaxAgeDuration:  48 * time.Hour,
+		},
+		Validator: &abci.ValidatorParams{
+			PubKeyTypes: []string{"ed25519"},
+		},
+	}
+}
+
+func endBlock(app *TestApp, height uint64) abci.ResponseEndBlock {
+	app.BeginBlock(abci.RequestBeginBlock{Header: abci.Header{
+		Height: int64(height),
+		Time:   time.Now(),
+	}})
+	resp := app.EndBlock(abci.RequestEndBlock{Height: int64(height)})
+	app.Commit()
+	return resp
+}
+
+func TestConsensusParamsAreUntrackedByDefault(t *testing.T) {
+	app, err := NewTestApp()
+	require.NoError(t, err)
+
+	resp := app.InitChain(abci.RequestInitChain{ConsensusParams: genesisParams()})
+	require.Equal(t, genesisParams(), resp.ConsensusParams)
+
+	_, tracked := app.GetConsensusParams()
+	require.False(t, tracked)
+	require.Error(t, app.ScheduleConsensusParams(5, metast.ConsensusParamsFrom(genesisParams())))
+
+	qresp := app.Query(abci.RequestQuery{Path: meta.ConsensusParamsEndpoint})
+	require.Equal(t, code.QueryError, code.ReturnCode(qresp.Code))
+}
+
+func TestConsensusParamsAreTracked(t *testing.T) {
+	app, err := NewTestApp()
+	require.NoError(t, err)
+
+	app.TrackConsensusParams()
+	app.InitChain(abci.RequestInitChain{ConsensusParams: genesisParams()})
+
+	cp, tracked := app.GetConsensusParams()
+	require.True(t, tracked)
+	require.Equal(t, genesisParams(), cp.ABCI())
+
+	qresp := app.Query(abci.RequestQuery{Path: meta.ConsensusParamsEndpoint})
+	require.Equal(t, code.OK, code.ReturnCode(qresp.Code))
+	queried := abci.ConsensusParams{}
+	require.NoError(t, queried.Unmarshal(qresp.Value))
+	require.Equal(t, genesisParams(), &queried)
+}
+
+func TestScheduledConsensusParamsAreReturnedFromEndBlock(t *testing.T) {
+	app, err := NewTestApp()
+	require.NoError(t, err)
+
+	app.TrackConsensusParams()
+	app.InitChain(abci.RequestInitChain{ConsensusParams: genesisParams()})
+
+	update := metast.ConsensusParamsFrom(genesisParams())
+	update.BlockMaxBytes = 1024 * 1024
+	require.NoError(t, app.ScheduleConsensusParams(4, update))
+
+	// invalid params can't be scheduled
+	require.Error(t, app.ScheduleConsensusParams(4, metast.ConsensusParams{}))
+
+	for h := uint64(2); h < 4; h++ {
+		require.Nil(t, endBlock(app, h).ConsensusParamUpdates)
+	}
+	resp := endBlock(app, 4)
+	require.Equal(t, update.ABCI(), resp.ConsensusParamUpdates)
+	require.Nil(t, endBlock(app, 5).ConsensusParamUpdates)
+
+	// the past is immutable
+	require.Error(t, app.ScheduleConsensusParams(4, update))
+
+	// the update must have been persisted
+	ms := metast.Metastate{}
+	_, err = ms.Load(app.GetDB(), app.GetDS(), &TestState{})
+	require.NoError(t, err)
+	require.Equal(t, update, ms.GetConsensusParams())
+	require.Empty(t, ms.GetScheduledConsensusParams())
+}
+
+func TestGenesisConsensusParamsCanBeOverridden(t *testing.T) {
+	app, err := NewTestApp()
+	require.NoError(t, err)
+
+	override := metast.ConsensusParamsFrom(genesisParams())
+	override.ValidatorPubKeyTypes = []string{"ed25519", "secp256k1"}
+
+	app.TrackConsensusParams()
+	require.NoError(t, app.ScheduleConsensusParams(app.Height(), override))
+	resp := app.InitChain(abci.RequestInitChain{ConsensusParams: genesisParams()})
+	require.Equal(t, override.ABCI(), resp.ConsensusParams)
+}
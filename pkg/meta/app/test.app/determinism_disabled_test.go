@@ -0,0 +1,32 @@
+//go:build !determinism
+
+package testapp
+
+// ----- ---- --- -- -
+// Copyright 2019, 2020 The Axiom Foundation. All Rights Reserved.
+//
+// Licensed under the Apache License 2.0 (the "License").  You may not use
+// this file except in compliance with the License.  You can obtain a copy
+// in the file LICENSE in the source distribution or at
+// https://www.apache.org/licenses/LICENSE-2.0.txt
+// - -- --- ---- -----
+
+import (
+	"testing"
+
+	meta "github.com/ndau/metanode/pkg/meta/app"
+	"github.com/ndau/metanode/pkg/meta/app/apptest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeterminismCheckRequiresBuildTag(t *testing.T) {
+	app, err := NewTestApp()
+	require.NoError(t, err)
+
+	require.Error(t, app.SetDeterminismCheck(&meta.DeterminismCheck{Trials: 1}))
+	require.NoError(t, app.SetDeterminismCheck(nil))
+
+	// txs are applied once, as usual
+	apptest.NewChain(t, app.App, TxIDs).Block(&Add{Qty: 1})
+	require.Equal(t, uint64(1), app.GetCount())
+}
@@ -0,0 +1,77 @@
+//go:build determinism
+
+package testapp
+
+// ----- ---- --- -- -
+// Copyright 2019, 2020 The Axiom Foundation. All Rights Reserved.
+//
+// Licensed under the Apache License 2.0 (the "License").  You may not use
+// this file except in compliance with the License.  You can obtain a copy
+// in the file LICENSE in the source distribution or at
+// https://www.apache.org/licenses/LICENSE-2.0.txt
+// - -- --- ---- -----
+
+import (
+	"testing"
+
+	meta "github.com/ndau/metanode/pkg/meta/app"
+	"github.com/ndau/metanode/pkg/meta/app/apptest"
+	metatx "github.com/ndau/metanode/pkg/meta/transaction"
+	"github.com/stretchr/testify/require"
+)
+
+var jitterTxIDs = metatx.TxIDMap{
+	metatx.TxID(1): &Add{},
+	metatx.TxID(2): &SetHistorySize{},
+	metatx.TxID(3): &Jitter{},
+}
+
+func newCheckedApp(t *testing.T, trials int) (*TestApp, *apptest.Chain, *[]meta.DeterminismViolation) {
+	app, err := NewTestAppWithTxIDs(jitterTxIDs)
+	require.NoError(t, err)
+	violations := new([]meta.DeterminismViolation)
+	require.NoError(t, app.SetDeterminismCheck(&meta.DeterminismCheck{
+		Trials:      trials,
+		ShuffleMaps: true,
+		Hook: func(v meta.DeterminismViolation) {
+			*violations = append(*violations, v)
+		},
+	}))
+	return app, apptest.NewChain(t, app.App, jitterTxIDs), violations
+}
+
+func TestDeterministicTxsPassDeterminismCheck(t *testing.T) {
+	app, chain, violations := newCheckedApp(t, 3)
+
+	chain.Block(&Add{Qty: 1}, &SetHistorySize{Size: 5})
+	chain.Block(&Add{Qty: 2})
+	require.Empty(t, *violations)
+
+	// the trials leave no trace: each tx was applied exactly once
+	require.Equal(t, uint64(3), app.GetCount())
+}
+
+func TestNondeterministicTxsFailDeterminismCheck(t *testing.T) {
+	app, chain, violations := newCheckedApp(t, 0)
+
+	chain.Block(&Add{Qty: 1})
+	chain.Block(&Jitter{Scale: 1})
+	require.Len(t, *violations, 1)
+
+	violation := (*violations)[0]
+	require.Equal(t, uint64(2), violation.Height)
+	require.Equal(t, &Jitter{Scale: 1}, violation.Tx)
+	require.NotEqual(t, violation.Expected, violation.Got)
+	require.Contains(t, violation.Diff, "Number")
+
+	// the real application still took effect
+	require.NotEqual(t, uint64(1), app.GetCount())
+}
+
+func TestDeterminismCheckIsOptional(t *testing.T) {
+	app, chain, violations := newCheckedApp(t, 1)
+	require.NoError(t, app.SetDeterminismCheck(nil))
+
+	chain.Block(&Jitter{Scale: 1})
+	require.Empty(t, *violations)
+}
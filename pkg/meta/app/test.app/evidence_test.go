@@ -0,0 +1,109 @@
+package testapp
+
+// ----- ---- --- -- -
+// Copyright 2019, 2020 The Axiom Foundation. All Rights Reserved.
+//
+// Licensed under the Apache License 2.0 (the "License").  You may not use
+// this file except in compliance with the License.  You can obtain a copy
+// in the file LICENSE in the source distribution or at
+// https://www.apache.org/licenses/LICENSE-2.0.txt
+// - -- --- ---- -----
+
+import (
+	"encoding/base64"
+	"testing"
+	"time"
+
+	metast "github.com/ndau/metanode/pkg/meta/state"
+	"github.com/stretchr/testify/require"
+	abci "github.com/tendermint/tendermint/abci/types"
+	"github.com/tendermint/tendermint/crypto/ed25519"
+)
+
+func TestEvidenceIsRecordedAndSlashed(t *testing.T) {
+	app, err := NewTestApp()
+	require.NoError(t, err)
+
+	pub := ed25519.GenPrivKey().PubKey().(ed25519.PubKeyEd25519)
+	app.InitChain(abci.RequestInitChain{
+		Validators: []abci.ValidatorUpdate{abci.Ed25519ValidatorUpdate(pub[:], 10)},
+	})
+	app.TrackEvidence()
+
+	slashed := 0
+	app.SetSlashingHook(func(appI interface{}, ev metast.Evidence) error {
+		slashed++
+		if ev.Validator != base64.StdEncoding.EncodeToString(pub.Address()) {
+			// not one of ours
+			return nil
+		}
+		appI.(*TestApp).UpdateValidator(abci.Ed25519ValidatorUpdate(pub[:], ev.Power/2))
+		return nil
+	})
+
+	stranger := []byte("not a validator here")
+	app.BeginBlock(abci.RequestBeginBlock{
+		Header: abci.Header{Height: 5, Time: time.Now()},
+		ByzantineValidators: []abci.Evidence{
+			{
+				Type:      "duplicate/vote",
+				Validator: abci.Validator{Address: pub.Address(), Power: 10},
+				Height:    4,
+			},
+			{
+				Type:      "duplicate/vote",
+				Validator: abci.Validator{Address: stranger, Power: 3},
+				Height:    1,
+			},
+		},
+	})
+	resp := app.EndBlock(abci.RequestEndBlock{Height: 5})
+	app.Commit()
+
+	// both pieces of evidence were handed to the hook, but only one slashed
+	require.Equal(t, 2, slashed)
+	require.Len(t, resp.ValidatorUpdates, 1)
+	require.Equal(t, int64(5), resp.ValidatorUpdates[0].Power)
+
+	expect := []metast.Evidence{
+		{
+			Type:           "duplicate/vote",
+			Validator:      base64.StdEncoding.EncodeToString(pub.Address()),
+			Power:          10,
+			Height:         4,
+			ReportedHeight: 5,
+		},
+		{
+			Type:           "duplicate/vote",
+			Validator:      base64.StdEncoding.EncodeToString(stranger),
+			Power:          3,
+			Height:         1,
+			ReportedHeight: 5,
+		},
+	}
+	require.Equal(t, expect, app.GetEvidence())
+
+	// the evidence must have been committed despite the block having no txs
+	ms := metast.Metastate{}
+	_, err = ms.Load(app.GetDB(), app.GetDS(), &TestState{})
+	require.NoError(t, err)
+	require.Equal(t, expect, ms.GetEvidence())
+	require.Equal(t, expect[1:], ms.EvidenceFor(expect[1].Validator))
+}
+
+func TestEvidenceIsNotPersistedByDefault(t *testing.T) {
+	app, err := NewTestApp()
+	require.NoError(t, err)
+
+	app.BeginBlock(abci.RequestBeginBlock{
+		Header: abci.Header{Height: 1, Time: time.Now()},
+		ByzantineValidators: []abci.Evidence{{
+			Type:      "duplicate/vote",
+			Validator: abci.Validator{Address: voterAddress, Power: 1},
+		}},
+	})
+	app.EndBlock(abci.RequestEndBlock{Height: 1})
+	app.Commit()
+
+	require.Nil(t, app.GetEvidence())
+}
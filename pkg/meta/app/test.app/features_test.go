@@ -0,0 +1,104 @@
+package testapp
+
+// ----- ---- --- -- -
+// Copyright 2019, 2020 The Axiom Foundation. All Rights Reserved.
+//
+// Licensed under the Apache License 2.0 (the "License").  You may not use
+// this file except in compliance with the License.  You can obtain a copy
+// in the file LICENSE in the source distribution or at
+// https://www.apache.org/licenses/LICENSE-2.0.txt
+// - -- --- ---- -----
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	meta "github.com/ndau/metanode/pkg/meta/app"
+	metast "github.com/ndau/metanode/pkg/meta/state"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+const strictUpdates = "StrictUpdates"
+
+// failingIncrement modifies the state in place, then fails
+func failingIncrement(st metast.State) (metast.State, error) {
+	state := st.(*TestState)
+	state.Number++
+	return state, errors.New("increment failed")
+}
+
+func TestUpdateStateGatedTogglesAtActivationHeight(t *testing.T) {
+	app, err := NewTestApp()
+	require.NoError(t, err)
+	require.NoError(t, app.RegisterFeature(strictUpdates, 3))
+
+	for h := uint64(1); h <= 4; h++ {
+		app.SetHeight(h)
+		before := app.GetCount()
+		require.Error(t, app.UpdateStateGated(strictUpdates, failingIncrement))
+		if h < 3 {
+			require.False(t, app.IsFeatureActive(strictUpdates))
+			// historical behavior: the failed update leaks into the state
+			require.Equal(t, before+1, app.GetCount())
+		} else {
+			require.True(t, app.IsFeatureActive(strictUpdates))
+			require.Equal(t, before, app.GetCount())
+		}
+	}
+}
+
+func TestFeatureRegistration(t *testing.T) {
+	app, err := NewTestApp()
+	require.NoError(t, err)
+
+	require.NoError(t, app.RegisterFeature(strictUpdates, 3))
+	require.Error(t, app.RegisterFeature(strictUpdates, 5))
+	require.Error(t, app.RegisterFeature("", 5))
+	require.Equal(t, meta.FeatureSchedule{strictUpdates: 3}, app.GetFeatureSchedule())
+
+	// misspelled features must not silently read as inactive
+	require.Panics(t, func() { app.IsFeatureActive("StrictUpdate") })
+	_, err = app.FeatureActive("StrictUpdate")
+	require.Error(t, err)
+	active, err := app.FeatureActive(strictUpdates)
+	require.NoError(t, err)
+	require.False(t, active)
+
+	// consensus code gets an error rather than a panic, and the state is untouched
+	before := app.GetCount()
+	require.NotPanics(t, func() {
+		err = app.UpdateStateGated("StrictUpdate", failingIncrement)
+	})
+	require.Error(t, err)
+	require.Equal(t, before, app.GetCount())
+}
+
+func TestFeatureHeightsCanBeConfigured(t *testing.T) {
+	app, err := NewTestApp()
+	require.NoError(t, err)
+	require.NoError(t, app.RegisterFeature(strictUpdates, 1000))
+	require.NoError(t, app.RegisterFeature("Other", 7))
+
+	dir, err := ioutil.TempDir("", "features")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "features.json")
+	require.NoError(t, ioutil.WriteFile(path, []byte(`{"StrictUpdates": 2}`), 0600))
+
+	schedule, err := meta.LoadFeatureSchedule(path)
+	require.NoError(t, err)
+	require.NoError(t, app.ConfigureFeatures(schedule))
+	require.Equal(t, meta.FeatureSchedule{strictUpdates: 2, "Other": 7}, app.GetFeatureSchedule())
+
+	app.SetHeight(2)
+	require.True(t, app.IsFeatureActive(strictUpdates))
+	require.False(t, app.IsFeatureActive("Other"))
+
+	// unknown features are rejected wholesale
+	err = app.ConfigureFeatures(meta.FeatureSchedule{"Other": 1, "Unknown": 1})
+	require.Error(t, err)
+	require.Equal(t, uint64(7), app.GetFeatureSchedule()["Other"])
+}
@@ -0,0 +1,75 @@
+//go:build go1.18
+
+package testapp
+
+// ----- ---- --- -- -
+// Copyright 2019, 2020 The Axiom Foundation. All Rights Reserved.
+//
+// Licensed under the Apache License 2.0 (the "License").  You may not use
+// this file except in compliance with the License.  You can obtain a copy
+// in the file LICENSE in the source distribution or at
+// https://www.apache.org/licenses/LICENSE-2.0.txt
+// - -- --- ---- -----
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ndau/metanode/pkg/meta/app/code"
+	metatx "github.com/ndau/metanode/pkg/meta/transaction"
+	txtest "github.com/ndau/metanode/pkg/meta/transaction/test"
+	"github.com/stretchr/testify/require"
+	abci "github.com/tendermint/tendermint/abci/types"
+)
+
+func FuzzCheckTx(f *testing.F) {
+	for _, seed := range []struct {
+		txab  metatx.Transactable
+		txIDs metatx.TxIDMap
+	}{
+		{&Add{Qty: 1}, TxIDs},
+		{&Add{Qty: -1}, TxIDs},
+		{&SetHistorySize{Size: 5}, TxIDs},
+		// unknown to the app
+		{&txtest.Stringy{S: "foo bar bat"}, txtest.Tmap},
+		{&txtest.Inty{I: 12345}, txtest.Tmap},
+	} {
+		b, err := metatx.Marshal(seed.txab, seed.txIDs)
+		require.NoError(f, err)
+		f.Add(b)
+		f.Add(b[:len(b)-1])
+	}
+	f.Add([]byte{})
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		// DeliverTx may modify the app, so each input gets a fresh one;
+		// otherwise, failures could depend on the inputs which came before
+		app, err := NewTestApp()
+		require.NoError(t, err)
+		count := app.GetCount()
+		resp := app.CheckTx(abci.RequestCheckTx{Tx: data})
+		rc := code.ReturnCode(resp.Code)
+		require.False(t, strings.HasPrefix(rc.String(), "ReturnCode("), "unknown return code %d", resp.Code)
+		require.NotEqual(t, code.ErrorApplyingTransaction, rc)
+		require.Equal(t, rc == code.OK, resp.Log == "", "log must explain exactly the failures")
+
+		// CheckTx is deterministic and never modifies the state
+		again := app.CheckTx(abci.RequestCheckTx{Tx: data})
+		require.Equal(t, resp.Code, again.Code)
+		require.Equal(t, count, app.GetCount())
+
+		// DeliverTx agrees with CheckTx about which txs are invalid.
+		// It doesn't enforce limits, so there's nothing to compare then.
+		if rc == code.TransactionLimitExceeded {
+			return
+		}
+		dresp := app.DeliverTx(abci.RequestDeliverTx{Tx: data})
+		drc := code.ReturnCode(dresp.Code)
+		if rc == code.OK {
+			require.Contains(t, []code.ReturnCode{code.OK, code.ErrorApplyingTransaction}, drc)
+		} else {
+			require.Equal(t, rc, drc)
+			require.Equal(t, count, app.GetCount())
+		}
+	})
+}
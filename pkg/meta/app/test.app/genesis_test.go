@@ -0,0 +1,204 @@
+package testapp
+
+// ----- ---- --- -- -
+// Copyright 2019, 2020 The Axiom Foundation. All Rights Reserved.
+//
+// Licensed under the Apache License 2.0 (the "License").  You may not use
+// this file except in compliance with the License.  You can obtain a copy
+// in the file LICENSE in the source distribution or at
+// https://www.apache.org/licenses/LICENSE-2.0.txt
+// - -- --- ---- -----
+
+import (
+	"testing"
+
+	meta "github.com/ndau/metanode/pkg/meta/app"
+	"github.com/ndau/metanode/pkg/meta/app/apptest"
+	metast "github.com/ndau/metanode/pkg/meta/state"
+	util "github.com/ndau/noms-util"
+	"github.com/stretchr/testify/require"
+	abci "github.com/tendermint/tendermint/abci/types"
+	tmtypes "github.com/tendermint/tendermint/types"
+)
+
+var genesisAppState = []byte(`{"Number": 42}`)
+
+func TestGenesisImport(t *testing.T) {
+	var hashes [][]byte
+	for i := 0; i < 2; i++ {
+		app, err := NewTestApp()
+		require.NoError(t, err)
+		app.SetGenesisDecoder(meta.JSONGenesisDecoder)
+
+		app.InitChain(abci.RequestInitChain{AppStateBytes: genesisAppState})
+		require.Equal(t, uint64(42), app.GetCount())
+		hashes = append(hashes, app.Hash())
+	}
+	// independent nodes must agree on the genesis app hash
+	require.Equal(t, hashes[0], hashes[1])
+}
+
+func TestGenesisAppHash(t *testing.T) {
+	newGenesis := func(appState string) *TestApp {
+		app, err := NewTestApp()
+		require.NoError(t, err)
+		app.SetGenesisDecoder(meta.JSONGenesisDecoder)
+		require.Nil(t, app.GenesisAppHash())
+		app.InitChain(abci.RequestInitChain{AppStateBytes: []byte(appState)})
+		require.NotEmpty(t, app.GenesisAppHash())
+		require.Equal(t, app.Hash(), app.GenesisAppHash())
+		return app
+	}
+
+	app := newGenesis(`{"Number": 42}`)
+	require.Equal(t, app.GenesisAppHash(), newGenesis(`{"Number": 42}`).GenesisAppHash())
+	require.NotEqual(t, app.GenesisAppHash(), newGenesis(`{"Number": 43}`).GenesisAppHash())
+
+	// the genesis app hash doesn't change as the chain advances
+	genesisHash := app.GenesisAppHash()
+	apptest.NewChain(t, app.App, TxIDs).Block(&Add{Qty: 1})
+	require.NotEqual(t, genesisHash, app.Hash())
+	require.Equal(t, genesisHash, app.GenesisAppHash())
+}
+
+func TestGenesisIgnoredWithoutDecoder(t *testing.T) {
+	app, err := NewTestApp()
+	require.NoError(t, err)
+
+	app.InitChain(abci.RequestInitChain{AppStateBytes: genesisAppState})
+	require.Equal(t, uint64(0), app.GetCount())
+}
+
+func TestGenesisImportRejectsBadState(t *testing.T) {
+	app, err := NewTestApp()
+	require.NoError(t, err)
+	app.SetGenesisDecoder(meta.JSONGenesisDecoder)
+
+	require.Panics(t, func() {
+		app.InitChain(abci.RequestInitChain{AppStateBytes: []byte(`{"Nmber": 42}`)})
+	})
+}
+
+func TestGenesisImportRejectsNondeterminism(t *testing.T) {
+	app, err := NewTestApp()
+	require.NoError(t, err)
+
+	calls := 0
+	app.SetGenesisDecoder(func(_ []byte, stI metast.State) (metast.State, error) {
+		calls++
+		st := stI.(*TestState)
+		st.Number = util.Int(calls)
+		return st, nil
+	})
+
+	require.Panics(t, func() {
+		app.InitChain(abci.RequestInitChain{AppStateBytes: genesisAppState})
+	})
+}
+
+func TestGenesisExportRoundtrip(t *testing.T) {
+	app, chain := initTest(t)
+	vus := makeValUpdates(10, 20)
+	app.TrackConsensusParams()
+	app.TrackVoteTotals()
+	app.TrackEvidence()
+	require.NoError(t, app.IndexValidatorAddresses())
+	require.NoError(t, app.SetVoteHistorySize(3))
+	require.NoError(t, app.ScheduleConsensusParams(100, metast.ConsensusParamsFrom(genesisParams())))
+	app.InitChain(abci.RequestInitChain{
+		Validators:      vus,
+		ConsensusParams: genesisParams(),
+	})
+	createStates(t, app, chain)
+
+	doc, err := app.ExportGenesis(meta.GenesisExport{
+		Height:  5,
+		ChainID: "test-chain",
+	})
+	require.NoError(t, err)
+	require.Equal(t, "test-chain", doc.ChainID)
+	require.Len(t, doc.Validators, 2)
+
+	// restart a chain from the exported genesis; the managed variables must
+	// be restored without the restarted app opting in to them
+	restarted, err := NewTestApp()
+	require.NoError(t, err)
+	restarted.SetGenesisDecoder(meta.JSONGenesisDecoder)
+
+	req := abci.RequestInitChain{
+		ChainId:         doc.ChainID,
+		ConsensusParams: tmtypes.TM2PB.ConsensusParams(doc.ConsensusParams),
+		AppStateBytes:   doc.AppState,
+	}
+	for _, v := range doc.Validators {
+		req.Validators = append(req.Validators, tmtypes.TM2PB.NewValidatorUpdate(v.PubKey, v.Power))
+	}
+	restarted.InitChain(req)
+
+	expect := getExpectedStateAtHeight(5)
+	require.Equal(t, uint64(expect.Number), restarted.GetCount())
+
+	expectVals, err := app.Validators()
+	require.NoError(t, err)
+	gotVals, err := restarted.Validators()
+	require.NoError(t, err)
+	require.Equal(t, expectVals, gotVals)
+
+	expectParams, _ := app.GetConsensusParams()
+	gotParams, _ := restarted.GetConsensusParams()
+	require.Equal(t, expectParams, gotParams)
+
+	exported, err := metast.MetastateAtHeight(app.GetDB(), app.GetDS(), &TestState{}, 5)
+	require.NoError(t, err)
+	imported := metast.Metastate{}
+	_, err = imported.Load(restarted.GetDB(), restarted.GetDS(), &TestState{})
+	require.NoError(t, err)
+	expectMeta := exported.ExportGenesis()
+	require.NotNil(t, expectMeta.VoteHistorySize)
+	require.NotNil(t, expectMeta.VoteTotals)
+	require.NotNil(t, expectMeta.Evidence)
+	require.NotEmpty(t, expectMeta.ValidatorAddresses)
+	require.Len(t, expectMeta.ScheduledConsensusParams, 1)
+	require.Equal(t, expectMeta, imported.ExportGenesis())
+}
+
+func TestGenesisImportOfEnvelope(t *testing.T) {
+	app, err := NewTestApp()
+	require.NoError(t, err)
+	app.SetGenesisDecoder(meta.JSONGenesisDecoder)
+
+	app.InitChain(abci.RequestInitChain{AppStateBytes: []byte(
+		`{"version": 1, "metastate": {"vote_history_size": 7}, "child": {"Number": 42}}`,
+	)})
+	require.Equal(t, uint64(42), app.GetCount())
+	ms := metast.Metastate{}
+	_, err = ms.Load(app.GetDB(), app.GetDS(), &TestState{})
+	require.NoError(t, err)
+	require.Equal(t, uint64(7), ms.GetVoteHistorySize())
+	require.False(t, ms.IsTrackingVoteTotals())
+
+	newer, err := NewTestApp()
+	require.NoError(t, err)
+	newer.SetGenesisDecoder(meta.JSONGenesisDecoder)
+	require.Panics(t, func() {
+		newer.InitChain(abci.RequestInitChain{AppStateBytes: []byte(
+			`{"version": 2, "metastate": {}, "child": {"Number": 42}}`,
+		)})
+	})
+}
+
+func TestGenesisExportOfCurrentHeight(t *testing.T) {
+	app, chain := initTest(t)
+	createStates(t, app, chain)
+
+	doc, err := app.ExportGenesis(meta.GenesisExport{ChainID: "test-chain"})
+	require.NoError(t, err)
+	// consensus params are not tracked, so tendermint's defaults apply
+	require.Equal(t, tmtypes.DefaultConsensusParams(), doc.ConsensusParams)
+
+	restarted, err := NewTestApp()
+	require.NoError(t, err)
+	restarted.SetGenesisDecoder(meta.JSONGenesisDecoder)
+	restarted.InitChain(abci.RequestInitChain{AppStateBytes: doc.AppState})
+	require.Equal(t, app.GetCount(), restarted.GetCount())
+}
@@ -18,53 +18,22 @@ package testapp
 
 import (
 	"testing"
-	"time"
 
+	"github.com/ndau/metanode/pkg/meta/app/apptest"
 	"github.com/ndau/metanode/pkg/meta/state"
-	metatx "github.com/ndau/metanode/pkg/meta/transaction"
 	util "github.com/ndau/noms-util"
+	"github.com/ndau/noms/go/datas"
+	nt "github.com/ndau/noms/go/types"
 	"github.com/stretchr/testify/require"
-	abci "github.com/tendermint/tendermint/abci/types"
 )
 
-type blockFactory struct {
-	app    *TestApp
-	t      *testing.T
-	height int64
-}
-
-func (bf *blockFactory) make(txab ...metatx.Transactable) {
-	bf.app.BeginBlock(abci.RequestBeginBlock{
-		Header: abci.Header{
-			Height: bf.height,
-			Time:   time.Now(),
-		},
-	})
-
-	for _, tx := range txab {
-		txb, err := metatx.Marshal(tx, TxIDs)
-		require.NoError(bf.t, err)
-		dtr := bf.app.DeliverTx(abci.RequestDeliverTx{Tx: txb})
-		require.True(bf.t, dtr.IsOK())
-	}
-
-	bf.app.EndBlock(abci.RequestEndBlock{Height: bf.height})
-	bf.app.Commit()
-
-	require.Equal(bf.t, bf.app.Height(), uint64(bf.height))
-	bf.height++
-}
-
-func initTest(t *testing.T) (*TestApp, blockFactory) {
+func initTest(t *testing.T) (*TestApp, *apptest.Chain) {
 	app, err := NewTestApp()
 	require.NoError(t, err)
 
-	return app,
-		blockFactory{
-			app:    app,
-			t:      t,
-			height: int64(app.Height()),
-		}
+	chain := apptest.NewChain(t, app.App, TxIDs)
+	chain.SetNextHeight(app.Height())
+	return app, chain
 }
 
 func TestEmptyAppHasNoHistory(t *testing.T) {
@@ -84,12 +53,12 @@ func TestEmptyAppHasNoHistory(t *testing.T) {
 func TestHistoryHasCorrectNumberOfStates(t *testing.T) {
 	qtyStates := 10
 
-	app, bf := initTest(t)
+	app, chain := initTest(t)
 
 	// make a bunch of states
 	// these can't be empty, because empty states aren't recorded in noms
 	for i := 0; i < qtyStates; i++ {
-		bf.make(&Add{1})
+		chain.Block(&Add{1})
 	}
 
 	// count the states present in the history
@@ -105,18 +74,18 @@ func TestHistoryHasCorrectNumberOfStates(t *testing.T) {
 }
 
 // create a bunch of states for which we can run tests
-func createStates(t *testing.T, app *TestApp, bf *blockFactory) {
-	bf.make() // tm height 0
-	bf.make() // tm height 1
-	bf.make() // tm height 2
-	bf.make() // tm height 3
+func createStates(t *testing.T, app *TestApp, chain *apptest.Chain) {
+	chain.Block() // tm height 0
+	chain.Block() // tm height 1
+	chain.Block() // tm height 2
+	chain.Block() // tm height 3
 	// set a k-v pair, incrementing the noms height to 3
-	bf.make(&Add{4}) // tm height 4
+	chain.Block(&Add{4}) // tm height 4
 	// don't mess with it for a while
-	bf.make() // tm height 5
-	bf.make() // tm height 6
-	bf.make(&Add{7})
-	bf.make(&Add{8})
+	chain.Block() // tm height 5
+	chain.Block() // tm height 6
+	chain.Block(&Add{7})
+	chain.Block(&Add{8})
 	require.Equal(t, uint64(8), app.Height())
 }
 
@@ -132,8 +101,8 @@ func getExpectedStateAtHeight(height uint64) TestState {
 
 // Test that we recover correct state at all points in history
 func TestHistoryCorrectlyRecoversStates(t *testing.T) {
-	app, bf := initTest(t)
-	createStates(t, app, &bf)
+	app, chain := initTest(t)
+	createStates(t, app, chain)
 
 	// ensure that at all points in history, the app.GetState() is what we expect
 	st := app.GetState().(*TestState)
@@ -149,8 +118,8 @@ func TestHistoryCorrectlyRecoversStates(t *testing.T) {
 }
 
 func TestStateAtHeight(t *testing.T) {
-	app, bf := initTest(t)
-	createStates(t, app, &bf)
+	app, chain := initTest(t)
+	createStates(t, app, chain)
 
 	// height 0 == "current height" in our semantics
 	for height := uint64(1); height <= 8; height++ {
@@ -160,3 +129,50 @@ func TestStateAtHeight(t *testing.T) {
 		require.Equal(t, getExpectedStateAtHeight(height), st)
 	}
 }
+
+func TestVerifyHistory(t *testing.T) {
+	app, chain := initTest(t)
+	createStates(t, app, chain)
+
+	qtyStates := uint64(0)
+	err := state.IterHistory(app.GetDB(), app.GetDS(), app.GetState(), func(state.State, uint64) error {
+		qtyStates++
+		return nil
+	})
+	require.NoError(t, err)
+
+	commits, err := state.VerifyHistory(app.GetDB(), app.GetDS())
+	require.NoError(t, err)
+	require.Equal(t, qtyStates, commits)
+}
+
+func TestVerifyHistoryDetectsMerges(t *testing.T) {
+	app, chain := initTest(t)
+	createStates(t, app, chain)
+
+	db := app.GetDB()
+	ds := app.GetDS()
+	head := ds.HeadRef()
+	parent := ds.Head().Get(datas.ParentsField).(nt.Set).First().(nt.Ref)
+	ds, err := db.Commit(ds, ds.HeadValue(), datas.CommitOptions{
+		Parents: nt.NewSet(db, head, parent),
+	})
+	require.NoError(t, err)
+
+	_, err = state.VerifyHistory(db, ds)
+	require.Error(t, err)
+}
+
+func TestRawStateReadsHistory(t *testing.T) {
+	app, chain := initTest(t)
+	createStates(t, app, chain)
+
+	var raw state.RawState
+	err := state.IterMetastates(app.GetDB(), app.GetDS(), &raw, func(metastate state.Metastate) error {
+		st := TestState{}
+		require.NoError(t, st.UnmarshalNoms(raw.Value))
+		require.Equal(t, getExpectedStateAtHeight(metastate.Height), st)
+		return nil
+	})
+	require.NoError(t, err)
+}
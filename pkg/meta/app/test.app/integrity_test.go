@@ -0,0 +1,111 @@
+package testapp
+
+// ----- ---- --- -- -
+// Copyright 2019, 2020 The Axiom Foundation. All Rights Reserved.
+//
+// Licensed under the Apache License 2.0 (the "License").  You may not use
+// this file except in compliance with the License.  You can obtain a copy
+// in the file LICENSE in the source distribution or at
+// https://www.apache.org/licenses/LICENSE-2.0.txt
+// - -- --- ---- -----
+
+// Like history_test.go, this exercises meta/state: here, integrity.go
+
+import (
+	"testing"
+
+	"github.com/ndau/metanode/pkg/meta/state"
+	"github.com/ndau/noms/go/datas"
+	nt "github.com/ndau/noms/go/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIntegrityOfHealthyHistory(t *testing.T) {
+	app, chain := initTest(t)
+	createStates(t, app, chain)
+
+	commits, err := state.VerifyHistory(app.GetDB(), app.GetDS())
+	require.NoError(t, err)
+
+	report, err := state.CheckIntegrity(app.GetDB(), app.GetDS(), &TestState{})
+	require.NoError(t, err)
+	require.True(t, report.OK(), "%v", report.Problems)
+	require.Equal(t, commits, report.Commits)
+	require.NotNil(t, report.LastGood)
+	require.Equal(t, app.GetDS().HeadRef(), *report.LastGood)
+	require.Equal(t, uint64(8), report.LastGoodHeight)
+
+	// a healthy dataset is never rewound
+	ds, err := state.RewindToLastGood(app.GetDB(), app.GetDS(), report)
+	require.NoError(t, err)
+	require.Equal(t, app.GetDS().HeadRef(), ds.HeadRef())
+}
+
+// checkRewind checks that the head of the app's dataset is reported as bad,
+// and that rewinding restores the good head
+func checkRewind(t *testing.T, app *TestApp, ds datas.Dataset, goodHead nt.Ref) {
+	report, err := state.CheckIntegrity(app.GetDB(), ds, &TestState{})
+	require.NoError(t, err)
+	require.False(t, report.OK())
+	require.Len(t, report.Problems, 1)
+	require.Equal(t, ds.HeadRef().TargetHash(), report.Problems[0].Commit)
+	require.NotNil(t, report.LastGood)
+	require.Equal(t, goodHead, *report.LastGood)
+	require.Equal(t, uint64(8), report.LastGoodHeight)
+
+	ds, err = state.RewindToLastGood(app.GetDB(), ds, report)
+	require.NoError(t, err)
+	require.Equal(t, goodHead, ds.HeadRef())
+
+	report, err = state.CheckIntegrity(app.GetDB(), ds, &TestState{})
+	require.NoError(t, err)
+	require.True(t, report.OK(), "%v", report.Problems)
+}
+
+func TestIntegrityDetectsDecreasingHeight(t *testing.T) {
+	app, chain := initTest(t)
+	createStates(t, app, chain)
+	goodHead := app.GetDS().HeadRef()
+
+	app.SetHeight(2)
+	require.NoError(t, app.UpdateCount(func(count *uint64) error {
+		*count++
+		return nil
+	}))
+	require.NoError(t, app.UpdateStateImmediately())
+
+	checkRewind(t, app, app.GetDS(), goodHead)
+}
+
+func TestIntegrityDetectsUnreadableMetastate(t *testing.T) {
+	app, chain := initTest(t)
+	createStates(t, app, chain)
+	goodHead := app.GetDS().HeadRef()
+
+	ds, err := app.GetDB().CommitValue(app.GetDS(), nt.String("not a metastate"))
+	require.NoError(t, err)
+
+	checkRewind(t, app, ds, goodHead)
+}
+
+func TestIntegrityDetectsMerges(t *testing.T) {
+	app, chain := initTest(t)
+	createStates(t, app, chain)
+
+	db := app.GetDB()
+	ds := app.GetDS()
+	parent := ds.Head().Get(datas.ParentsField).(nt.Set).First().(nt.Ref)
+	ds, err := db.Commit(ds, ds.HeadValue(), datas.CommitOptions{
+		Parents: nt.NewSet(db, ds.HeadRef(), parent),
+	})
+	require.NoError(t, err)
+
+	report, err := state.CheckIntegrity(db, ds, &TestState{})
+	require.NoError(t, err)
+	require.False(t, report.OK())
+	require.Equal(t, uint64(1), report.Commits)
+	// the ancestry of a merge is ambiguous, so nothing is known to be good
+	require.Nil(t, report.LastGood)
+	_, err = state.RewindToLastGood(db, ds, report)
+	require.Error(t, err)
+}
@@ -0,0 +1,124 @@
+package testapp
+
+// ----- ---- --- -- -
+// Copyright 2019, 2020 The Axiom Foundation. All Rights Reserved.
+//
+// Licensed under the Apache License 2.0 (the "License").  You may not use
+// this file except in compliance with the License.  You can obtain a copy
+// in the file LICENSE in the source distribution or at
+// https://www.apache.org/licenses/LICENSE-2.0.txt
+// - -- --- ---- -----
+
+import (
+	"testing"
+
+	"github.com/ndau/metanode/pkg/meta/app/code"
+	metatx "github.com/ndau/metanode/pkg/meta/transaction"
+	"github.com/stretchr/testify/require"
+	abci "github.com/tendermint/tendermint/abci/types"
+)
+
+func lifecycleRegistry(t *testing.T) *metatx.Registry {
+	registry, err := metatx.RegistryFrom(TxIDs)
+	require.NoError(t, err)
+	require.NoError(t, registry.SetMetadata(1, metatx.TxMetadata{
+		ValidUntil:  3,
+		Deprecation: "Add is retired",
+		Successor:   2,
+	}))
+	require.NoError(t, registry.SetMetadata(2, metatx.TxMetadata{
+		ValidFrom: 2,
+	}))
+	return registry
+}
+
+func TestTxLifecycleIsEnforced(t *testing.T) {
+	registry := lifecycleRegistry(t)
+	app, err := NewTestAppWithTxIDs(registry)
+	require.NoError(t, err)
+
+	add, err := metatx.MarshalWithResolver(&Add{Qty: 1}, registry)
+	require.NoError(t, err)
+	setSize, err := metatx.MarshalWithResolver(&SetHistorySize{Size: 5}, registry)
+	require.NoError(t, err)
+
+	expect := map[uint64][2]code.ReturnCode{
+		1: {code.OK, code.TransactionNotYetValid},
+		2: {code.OK, code.OK},
+		3: {code.OK, code.OK},
+		4: {code.DeprecatedTransaction, code.OK},
+		5: {code.DeprecatedTransaction, code.OK},
+	}
+	for height := uint64(1); height <= 4; height++ {
+		app.SetHeight(height)
+		for i, tx := range [][]byte{add, setSize} {
+			resp := app.DeliverTx(abci.RequestDeliverTx{Tx: tx})
+			require.Equal(t, expect[height][i], code.ReturnCode(resp.Code), "height %d tx %d", height, i)
+			// CheckTx runs between blocks: the tx would land in the next one
+			checkResp := app.CheckTx(abci.RequestCheckTx{Tx: tx})
+			require.Equal(t, expect[height+1][i], code.ReturnCode(checkResp.Code), "height %d tx %d", height, i)
+		}
+	}
+
+	// the add was applied at heights 1 through 3
+	require.Equal(t, uint64(3), app.GetCount())
+
+	// the sunset error explains what to do instead
+	resp := app.CheckTx(abci.RequestCheckTx{Tx: add})
+	require.Contains(t, resp.Log, "Add is retired")
+	require.Contains(t, resp.Log, "SetHistorySize")
+}
+
+func TestCheckTxLifecycleBoundaries(t *testing.T) {
+	registry := lifecycleRegistry(t)
+	app, err := NewTestAppWithTxIDs(registry)
+	require.NoError(t, err)
+
+	add, err := metatx.MarshalWithResolver(&Add{Qty: 1}, registry)
+	require.NoError(t, err)
+	setSize, err := metatx.MarshalWithResolver(&SetHistorySize{Size: 5}, registry)
+	require.NoError(t, err)
+
+	check := func(committed uint64, tx []byte) code.ReturnCode {
+		app.SetHeight(committed)
+		return code.ReturnCode(app.CheckTx(abci.RequestCheckTx{Tx: tx}).Code)
+	}
+
+	// SetHistorySize is valid from height 2, so it enters the mempool once
+	// height 1 is committed
+	require.Equal(t, code.TransactionNotYetValid, check(0, setSize))
+	require.Equal(t, code.OK, check(1, setSize))
+
+	// Add is valid until height 3, so it leaves the mempool once height 3
+	// is committed
+	require.Equal(t, code.OK, check(2, add))
+	require.Equal(t, code.DeprecatedTransaction, check(3, add))
+}
+
+func TestHistoricalTxsReplayDespiteSunset(t *testing.T) {
+	registry := lifecycleRegistry(t)
+	app, err := NewTestAppWithTxIDs(registry)
+	require.NoError(t, err)
+
+	add, err := metatx.MarshalWithResolver(&Add{Qty: 1}, registry)
+	require.NoError(t, err)
+
+	// replaying a block from before the sunset height accepts the tx
+	app.SetHeight(2)
+	resp := app.DeliverTx(abci.RequestDeliverTx{Tx: add})
+	require.Equal(t, code.OK, code.ReturnCode(resp.Code))
+}
+
+func TestTxMetadataValidation(t *testing.T) {
+	registry, err := metatx.RegistryFrom(TxIDs)
+	require.NoError(t, err)
+
+	require.Error(t, registry.SetMetadata(3, metatx.TxMetadata{}))
+	require.Error(t, registry.SetMetadata(1, metatx.TxMetadata{ValidFrom: 5, ValidUntil: 4}))
+	require.Error(t, registry.SetMetadata(1, metatx.TxMetadata{Successor: 1}))
+	require.Error(t, registry.SetMetadata(1, metatx.TxMetadata{Successor: 3}))
+
+	md, ok := registry.Metadata(1)
+	require.True(t, ok)
+	require.False(t, md.IsDeprecated())
+}
@@ -0,0 +1,57 @@
+package testapp
+
+// ----- ---- --- -- -
+// Copyright 2019, 2020 The Axiom Foundation. All Rights Reserved.
+//
+// Licensed under the Apache License 2.0 (the "License").  You may not use
+// this file except in compliance with the License.  You can obtain a copy
+// in the file LICENSE in the source distribution or at
+// https://www.apache.org/licenses/LICENSE-2.0.txt
+// - -- --- ---- -----
+
+import (
+	"testing"
+
+	"github.com/ndau/metanode/pkg/meta/app/code"
+	metatx "github.com/ndau/metanode/pkg/meta/transaction"
+	"github.com/stretchr/testify/require"
+	abci "github.com/tendermint/tendermint/abci/types"
+	"github.com/tinylib/msgp/msgp"
+)
+
+func TestCheckTxEnforcesLimits(t *testing.T) {
+	app, err := NewTestApp()
+	require.NoError(t, err)
+	require.Equal(t, metatx.DefaultLimits, app.TxLimits())
+
+	txBytes, err := metatx.Marshal(&Add{Qty: 1}, TxIDs)
+	require.NoError(t, err)
+	resp := app.CheckTx(abci.RequestCheckTx{Tx: txBytes})
+	require.Equal(t, code.OK, code.ReturnCode(resp.Code))
+
+	app.SetTxLimits(metatx.Limits{MaxTxBytes: len(txBytes) - 1})
+	resp = app.CheckTx(abci.RequestCheckTx{Tx: txBytes})
+	require.Equal(t, code.TransactionLimitExceeded, code.ReturnCode(resp.Code))
+	require.NotEmpty(t, resp.Log)
+
+	// DeliverTx doesn't enforce limits, so that historical blocks replay
+	dresp := app.DeliverTx(abci.RequestDeliverTx{Tx: txBytes})
+	require.Equal(t, code.OK, code.ReturnCode(dresp.Code))
+}
+
+func TestCheckTxRejectsMalformedLengths(t *testing.T) {
+	app, err := NewTestApp()
+	require.NoError(t, err)
+	app.SetTxLimits(metatx.Limits{})
+
+	txn := metatx.Transaction{
+		Nonce:          []byte("nonce"),
+		TransactableID: 1,
+		Transactable:   msgp.AppendArrayHeader(nil, 1<<31),
+	}
+	txBytes, err := txn.MarshalMsg(nil)
+	require.NoError(t, err)
+
+	resp := app.CheckTx(abci.RequestCheckTx{Tx: txBytes})
+	require.Equal(t, code.EncodingError, code.ReturnCode(resp.Code))
+}
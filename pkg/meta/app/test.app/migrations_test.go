@@ -0,0 +1,154 @@
+package testapp
+
+// ----- ---- --- -- -
+// Copyright 2019, 2020 The Axiom Foundation. All Rights Reserved.
+//
+// Licensed under the Apache License 2.0 (the "License").  You may not use
+// this file except in compliance with the License.  You can obtain a copy
+// in the file LICENSE in the source distribution or at
+// https://www.apache.org/licenses/LICENSE-2.0.txt
+// - -- --- ---- -----
+
+import (
+	"testing"
+
+	meta "github.com/ndau/metanode/pkg/meta/app"
+	metast "github.com/ndau/metanode/pkg/meta/state"
+	util "github.com/ndau/noms-util"
+	"github.com/stretchr/testify/require"
+	abci "github.com/tendermint/tendermint/abci/types"
+)
+
+func doubleNumber(st metast.State) (metast.State, error) {
+	state := st.(*TestState)
+	state.Number *= 2
+	return state, nil
+}
+
+func TestMigrationRunsAtHeight(t *testing.T) {
+	app, err := NewTestApp()
+	require.NoError(t, err)
+	app.SetGenesisDecoder(meta.JSONGenesisDecoder)
+	app.InitChain(abci.RequestInitChain{AppStateBytes: genesisAppState})
+	hashBefore := app.HashStr()
+
+	require.NoError(t, app.RegisterMigration(meta.Migration{
+		Version: 1,
+		Height:  3,
+		Migrate: doubleNumber,
+	}))
+
+	for h := uint64(1); h < 3; h++ {
+		endBlock(app, h)
+		require.Equal(t, uint64(42), app.GetCount())
+		require.Equal(t, uint64(0), app.SchemaVersion())
+	}
+	// registering migrations doesn't change the app hash until they run
+	require.Equal(t, hashBefore, app.HashStr())
+
+	endBlock(app, 3)
+	require.Equal(t, uint64(84), app.GetCount())
+	require.Equal(t, uint64(1), app.SchemaVersion())
+
+	// migrations run only once
+	endBlock(app, 4)
+	require.Equal(t, uint64(84), app.GetCount())
+
+	// the migration must have been committed despite the block having no txs
+	ms := metast.Metastate{}
+	_, err = ms.Load(app.GetDB(), app.GetDS(), &TestState{})
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), ms.GetSchemaVersion())
+	require.Equal(t, uint64(84), uint64(ms.ChildState.(*TestState).Number))
+}
+
+func TestMigrationsCatchUp(t *testing.T) {
+	app, err := NewTestApp()
+	require.NoError(t, err)
+	app.SetGenesisDecoder(meta.JSONGenesisDecoder)
+	app.InitChain(abci.RequestInitChain{AppStateBytes: genesisAppState})
+
+	for v := uint64(1); v <= 2; v++ {
+		require.NoError(t, app.RegisterMigration(meta.Migration{
+			Version: v,
+			Height:  2,
+			Migrate: doubleNumber,
+		}))
+	}
+
+	// both migrations are due at the same height, and run in order
+	endBlock(app, 2)
+	require.Equal(t, uint64(168), app.GetCount())
+	require.Equal(t, uint64(2), app.SchemaVersion())
+}
+
+func TestMigrationRegistration(t *testing.T) {
+	app, err := NewTestApp()
+	require.NoError(t, err)
+
+	require.Error(t, app.RegisterMigration(meta.Migration{Version: 1, Height: 3}))
+	require.Error(t, app.RegisterMigration(meta.Migration{Version: 2, Height: 3, Migrate: doubleNumber}))
+	require.NoError(t, app.RegisterMigration(meta.Migration{Version: 1, Height: 3, Migrate: doubleNumber}))
+	require.Error(t, app.RegisterMigration(meta.Migration{Version: 1, Height: 5, Migrate: doubleNumber}))
+	require.Error(t, app.RegisterMigration(meta.Migration{Version: 2, Height: 2, Migrate: doubleNumber}))
+	require.NoError(t, app.RegisterMigration(meta.Migration{Version: 2, Height: 5, Migrate: doubleNumber}))
+}
+
+func TestNondeterministicMigrationPanics(t *testing.T) {
+	app, err := NewTestApp()
+	require.NoError(t, err)
+	app.InitChain(abci.RequestInitChain{})
+
+	calls := 0
+	require.NoError(t, app.RegisterMigration(meta.Migration{
+		Version: 1,
+		Height:  2,
+		Migrate: func(st metast.State) (metast.State, error) {
+			calls++
+			state := st.(*TestState)
+			state.Number = util.Int(calls)
+			return state, nil
+		},
+	}))
+
+	require.Panics(t, func() { endBlock(app, 2) })
+}
+
+func TestMigrationsDontRerunAfterGenesisExport(t *testing.T) {
+	register := func(app *TestApp) {
+		require.NoError(t, app.RegisterMigration(meta.Migration{
+			Version: 1,
+			Height:  2,
+			Migrate: doubleNumber,
+		}))
+	}
+
+	app, err := NewTestApp()
+	require.NoError(t, err)
+	app.SetGenesisDecoder(meta.JSONGenesisDecoder)
+	app.InitChain(abci.RequestInitChain{AppStateBytes: genesisAppState})
+	register(app)
+	for h := uint64(1); h <= 3; h++ {
+		endBlock(app, h)
+	}
+	require.Equal(t, uint64(84), app.GetCount())
+	require.Equal(t, uint64(1), app.SchemaVersion())
+
+	doc, err := app.ExportGenesis(meta.GenesisExport{ChainID: "test-chain"})
+	require.NoError(t, err)
+
+	// the restarted chain registers the same migrations, as child apps do
+	restarted, err := NewTestApp()
+	require.NoError(t, err)
+	restarted.SetGenesisDecoder(meta.JSONGenesisDecoder)
+	register(restarted)
+	restarted.InitChain(abci.RequestInitChain{AppStateBytes: doc.AppState})
+	require.Equal(t, uint64(1), restarted.SchemaVersion())
+
+	// the migration height is passed again, but the state was already migrated
+	for h := uint64(1); h <= 3; h++ {
+		endBlock(restarted, h)
+		require.Equal(t, uint64(84), restarted.GetCount())
+		require.Equal(t, uint64(1), restarted.SchemaVersion())
+	}
+}
@@ -0,0 +1,163 @@
+package testapp
+
+// ----- ---- --- -- -
+// Copyright 2019, 2020 The Axiom Foundation. All Rights Reserved.
+//
+// Licensed under the Apache License 2.0 (the "License").  You may not use
+// this file except in compliance with the License.  You can obtain a copy
+// in the file LICENSE in the source distribution or at
+// https://www.apache.org/licenses/LICENSE-2.0.txt
+// - -- --- ---- -----
+
+import (
+	"encoding/binary"
+	"testing"
+
+	meta "github.com/ndau/metanode/pkg/meta/app"
+	"github.com/ndau/metanode/pkg/meta/app/apptest"
+	"github.com/ndau/metanode/pkg/meta/app/code"
+	metast "github.com/ndau/metanode/pkg/meta/state"
+	metatx "github.com/ndau/metanode/pkg/meta/transaction"
+	log "github.com/sirupsen/logrus"
+	logtest "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/require"
+	abci "github.com/tendermint/tendermint/abci/types"
+)
+
+// initReadOnly writes a few blocks to an nbs database, then reopens it
+// read-only
+func initReadOnly(t *testing.T) (*TestApp, func()) {
+	config, cleanup := tempNBS(t)
+
+	app, err := NewTestAppWithStorage(config)
+	require.NoError(t, err)
+	chain := apptest.NewChain(t, app.App, TxIDs)
+	for i := 0; i < 3; i++ {
+		chain.Block(&Add{Qty: 1})
+	}
+	require.NoError(t, app.Close())
+
+	app, err = NewReadOnlyTestApp(config)
+	require.NoError(t, err)
+	return app, func() {
+		app.Close()
+		cleanup()
+	}
+}
+
+func TestReadOnlyAppLoadsState(t *testing.T) {
+	app, cleanup := initReadOnly(t)
+	defer cleanup()
+
+	require.True(t, app.IsReadOnly())
+	require.Equal(t, uint64(3), app.Height())
+	require.Equal(t, uint64(3), app.GetCount())
+}
+
+func TestReadOnlyAppServesQueries(t *testing.T) {
+	app, cleanup := initReadOnly(t)
+	defer cleanup()
+
+	resp := app.Query(abci.RequestQuery{Path: ValueEndpoint})
+	require.Equal(t, code.OK, code.ReturnCode(resp.Code))
+	require.Equal(t, uint64(3), binary.BigEndian.Uint64(resp.Value))
+}
+
+func TestReadOnlyAppServesHistory(t *testing.T) {
+	app, cleanup := initReadOnly(t)
+	defer cleanup()
+
+	for height := uint64(1); height <= 3; height++ {
+		st := TestState{}
+		require.NoError(t, metast.AtHeight(app.GetDB(), app.GetDS(), &st, height))
+		require.Equal(t, height, uint64(st.Number))
+	}
+}
+
+func TestReadOnlyAppRejectsWrites(t *testing.T) {
+	app, cleanup := initReadOnly(t)
+	defer cleanup()
+	hash := app.Hash()
+
+	tx, err := metatx.Marshal(&Add{Qty: 1}, TxIDs)
+	require.NoError(t, err)
+	resp := app.DeliverTx(abci.RequestDeliverTx{Tx: tx})
+	require.Equal(t, code.InvalidNodeState, code.ReturnCode(resp.Code))
+	require.Equal(t, meta.ErrReadOnly.Error(), resp.Log)
+	require.Equal(t, uint64(3), app.GetCount())
+
+	require.Equal(t, hash, app.Commit().Data)
+
+	err = app.UpdateStateImmediately(func(st metast.State) (metast.State, error) {
+		st.(*TestState).Number++
+		return st, nil
+	})
+	require.Equal(t, meta.ErrReadOnly, err)
+	require.Equal(t, uint64(3), app.GetCount())
+
+	snapshot, err := apptest.SnapshotApp(app.App)
+	require.NoError(t, err)
+	require.Error(t, apptest.RestoreApp(app.App, snapshot))
+	require.Equal(t, hash, app.Hash())
+}
+
+func TestReadOnlyAppIgnoresBlocks(t *testing.T) {
+	app, cleanup := initReadOnly(t)
+	defer cleanup()
+	hash := app.Hash()
+	stats := app.GetStats()
+	logger, hook := logtest.NewNullLogger()
+	app.SetLogger(logger)
+
+	// a migration which would run at the next block
+	require.NoError(t, app.RegisterMigration(meta.Migration{
+		Version: 1,
+		Height:  4,
+		Migrate: doubleNumber,
+	}))
+
+	require.NotPanics(t, func() {
+		resp := app.InitChain(abci.RequestInitChain{
+			Validators:    makeValUpdates(10),
+			AppStateBytes: genesisAppState,
+		})
+		require.Empty(t, resp.Validators)
+	})
+
+	app.BeginBlock(abci.RequestBeginBlock{
+		Header: abci.Header{Height: 4, Time: apptest.GenesisTime},
+		LastCommitInfo: abci.LastCommitInfo{Votes: []abci.VoteInfo{{
+			Validator:       abci.Validator{Address: []byte("validator"), Power: 10},
+			SignedLastBlock: true,
+		}}},
+	})
+	resp := app.EndBlock(abci.RequestEndBlock{Height: 4})
+	require.Empty(t, resp.ValidatorUpdates)
+	require.Nil(t, resp.ConsensusParamUpdates)
+	require.Equal(t, hash, app.Commit().Data)
+
+	require.Equal(t, uint64(3), app.Height())
+	require.Equal(t, uint64(3), app.GetCount())
+	require.Equal(t, uint64(0), app.SchemaVersion())
+	require.Equal(t, stats, app.GetStats())
+	require.Equal(t, hash, app.Hash())
+
+	// every refusal is logged
+	refusals := make(map[string]interface{})
+	for _, entry := range hook.AllEntries() {
+		if entry.Level == log.ErrorLevel {
+			refusals[entry.Message] = entry.Data[log.ErrorKey]
+		}
+	}
+	require.Equal(t, map[string]interface{}{
+		"InitChain refused": meta.ErrReadOnly,
+		"BeginBlock erred":  meta.ErrReadOnly,
+		"EndBlock refused":  meta.ErrReadOnly,
+		"Commit erred":      meta.ErrReadOnly,
+	}, refusals)
+}
+
+func TestReadOnlyAppRequiresPersistentStorage(t *testing.T) {
+	_, err := NewReadOnlyTestApp(meta.MemoryStorage())
+	require.Error(t, err)
+}
@@ -0,0 +1,73 @@
+package testapp
+
+// ----- ---- --- -- -
+// Copyright 2019, 2020 The Axiom Foundation. All Rights Reserved.
+//
+// Licensed under the Apache License 2.0 (the "License").  You may not use
+// this file except in compliance with the License.  You can obtain a copy
+// in the file LICENSE in the source distribution or at
+// https://www.apache.org/licenses/LICENSE-2.0.txt
+// - -- --- ---- -----
+
+import (
+	"testing"
+
+	meta "github.com/ndau/metanode/pkg/meta/app"
+	"github.com/ndau/metanode/pkg/meta/app/apptest"
+	"github.com/ndau/metanode/pkg/meta/app/code"
+	metast "github.com/ndau/metanode/pkg/meta/state"
+	util "github.com/ndau/noms-util"
+	"github.com/stretchr/testify/require"
+)
+
+func newReplicas(t *testing.T, n int) ([]*TestApp, *apptest.Replicas) {
+	apps := make([]*TestApp, 0, n)
+	metaApps := make([]*meta.App, 0, n)
+	for i := 0; i < n; i++ {
+		app, err := NewTestApp()
+		require.NoError(t, err)
+		app.TrackVoteTotals()
+		apps = append(apps, app)
+		metaApps = append(metaApps, app.App)
+	}
+	return apps, apptest.NewReplicas(t, TxIDs, metaApps...)
+}
+
+func TestReplicasAgree(t *testing.T) {
+	apps, replicas := newReplicas(t, 3)
+	replicas.SetVotes(apptest.Vote(voterAddress, 10, true))
+
+	replicas.Block(&Add{Qty: 1}, &SetHistorySize{Size: 3})
+	replicas.Blocks(2)
+	replicas.BlockWith(
+		apptest.Expect(code.OK, &Add{Qty: 2}),
+		apptest.Expect(code.InvalidTransaction, &Add{Qty: -2}),
+	)
+
+	require.NoError(t, replicas.Compare())
+	for _, app := range apps {
+		require.Equal(t, uint64(3), app.GetCount())
+		require.Equal(t, apps[0].Hash(), app.Hash())
+	}
+}
+
+func TestReplicasDetectDivergence(t *testing.T) {
+	apps, replicas := newReplicas(t, 3)
+	replicas.Block(&Add{Qty: 1})
+
+	// simulate a nondeterministic tx on one replica
+	err := apps[2].UpdateStateImmediately(func(st metast.State) (metast.State, error) {
+		st.(*TestState).Number = util.Int(1000)
+		return st, nil
+	})
+	require.NoError(t, err)
+
+	err = replicas.Compare()
+	require.Error(t, err)
+	divergence, ok := err.(apptest.DivergenceError)
+	require.True(t, ok)
+	require.Equal(t, 2, divergence.Replica)
+	require.Equal(t, apps[0].HashStr(), divergence.Hashes[0])
+	require.Equal(t, apps[2].HashStr(), divergence.Hashes[1])
+	require.Contains(t, divergence.Diff, "Number")
+}
@@ -0,0 +1,28 @@
+package testapp
+
+// ----- ---- --- -- -
+// Copyright 2019, 2020 The Axiom Foundation. All Rights Reserved.
+//
+// Licensed under the Apache License 2.0 (the "License").  You may not use
+// this file except in compliance with the License.  You can obtain a copy
+// in the file LICENSE in the source distribution or at
+// https://www.apache.org/licenses/LICENSE-2.0.txt
+// - -- --- ---- -----
+
+import (
+	"math/rand"
+	"testing"
+
+	metast "github.com/ndau/metanode/pkg/meta/state"
+	"github.com/ndau/metanode/pkg/meta/state/statetest"
+	util "github.com/ndau/noms-util"
+)
+
+func TestTestStateConformance(t *testing.T) {
+	statetest.Run(t, func(r *rand.Rand) metast.State {
+		if r.Intn(4) == 0 {
+			return &TestState{}
+		}
+		return &TestState{Number: util.Int(r.Int63())}
+	}, statetest.Options{})
+}
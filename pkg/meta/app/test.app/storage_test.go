@@ -0,0 +1,161 @@
+package testapp
+
+// ----- ---- --- -- -
+// Copyright 2019, 2020 The Axiom Foundation. All Rights Reserved.
+//
+// Licensed under the Apache License 2.0 (the "License").  You may not use
+// this file except in compliance with the License.  You can obtain a copy
+// in the file LICENSE in the source distribution or at
+// https://www.apache.org/licenses/LICENSE-2.0.txt
+// - -- --- ---- -----
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	meta "github.com/ndau/metanode/pkg/meta/app"
+	"github.com/ndau/metanode/pkg/meta/app/apptest"
+	metast "github.com/ndau/metanode/pkg/meta/state"
+	nt "github.com/ndau/noms/go/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseStorageSpec(t *testing.T) {
+	cases := []struct {
+		spec    string
+		want    meta.StorageConfig
+		wantErr bool
+	}{
+		{"", meta.MemoryStorage(), false},
+		{"mem", meta.MemoryStorage(), false},
+		{"/var/noms", meta.StorageConfig{Backend: meta.NBSBackend, Path: "/var/noms"}, false},
+		{"nbs:/var/noms", meta.StorageConfig{Backend: meta.NBSBackend, Path: "/var/noms"}, false},
+		{"http://noms:8000", meta.StorageConfig{Backend: meta.HTTPBackend, Path: "http://noms:8000"}, false},
+		{"https://noms", meta.StorageConfig{Backend: meta.HTTPBackend, Path: "https://noms"}, false},
+		{"mem::ndau", meta.StorageConfig{}, true},
+		{"/var/noms::ndau", meta.StorageConfig{}, true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.spec, func(t *testing.T) {
+			got, err := meta.ParseStorageSpec(tc.spec)
+			if tc.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.want, got)
+			require.NoError(t, got.Validate())
+		})
+	}
+}
+
+func TestStorageConfigValidation(t *testing.T) {
+	invalid := map[string]meta.StorageConfig{
+		"unknown backend":      {Backend: "leveldb", Path: "/var/noms"},
+		"mem with a path":      {Backend: meta.MemoryBackend, Path: "/var/noms"},
+		"read-only mem":        {Backend: meta.MemoryBackend, ReadOnly: true},
+		"nbs without a path":   {Backend: meta.NBSBackend},
+		"http without a url":   {Backend: meta.HTTPBackend, Path: "/var/noms"},
+		"mem with a mem table": {Backend: meta.MemoryBackend, MemTableSize: 1024},
+		"invalid dataset":      {Backend: meta.MemoryBackend, Dataset: "ndau::main"},
+	}
+	for name, config := range invalid {
+		t.Run(name, func(t *testing.T) {
+			require.Error(t, config.Validate())
+			_, err := NewTestAppWithStorage(config)
+			require.Error(t, err)
+		})
+	}
+}
+
+func tempNBS(t *testing.T) (meta.StorageConfig, func()) {
+	dir, err := ioutil.TempDir("", "metanode-nbs")
+	require.NoError(t, err)
+	return meta.StorageConfig{
+		Backend:      meta.NBSBackend,
+		Path:         dir,
+		MemTableSize: 1024 * 1024,
+	}, func() { os.RemoveAll(dir) }
+}
+
+func TestNBSStoragePersists(t *testing.T) {
+	config, cleanup := tempNBS(t)
+	defer cleanup()
+
+	app, err := NewTestAppWithStorage(config)
+	require.NoError(t, err)
+	chain := apptest.NewChain(t, app.App, TxIDs)
+	chain.Block(&Add{Qty: 3})
+	hash := app.Hash()
+	require.NoError(t, app.Close())
+
+	app, err = NewTestAppWithStorage(config)
+	require.NoError(t, err)
+	defer app.Close()
+	require.Equal(t, uint64(3), app.GetCount())
+	require.Equal(t, uint64(1), app.Height())
+	require.Equal(t, hash, app.Hash())
+}
+
+func TestDatasetsAreIndependent(t *testing.T) {
+	config, cleanup := tempNBS(t)
+	defer cleanup()
+
+	config.Dataset = "first"
+	app, err := NewTestAppWithStorage(config)
+	require.NoError(t, err)
+	apptest.NewChain(t, app.App, TxIDs).Block(&Add{Qty: 3})
+	require.NoError(t, app.Close())
+
+	config.Dataset = "second"
+	app, err = NewTestAppWithStorage(config)
+	require.NoError(t, err)
+	defer app.Close()
+	require.Equal(t, uint64(0), app.GetCount())
+	require.Empty(t, app.Hash())
+}
+
+func TestReadOnlyStorageRefusesToCommit(t *testing.T) {
+	config, cleanup := tempNBS(t)
+	defer cleanup()
+
+	app, err := NewTestAppWithStorage(config)
+	require.NoError(t, err)
+	apptest.NewChain(t, app.App, TxIDs).Block(&Add{Qty: 3})
+	hash := app.Hash()
+	require.NoError(t, app.Close())
+
+	config.ReadOnly = true
+	app, err = NewTestAppWithStorage(config)
+	require.NoError(t, err)
+	defer app.Close()
+	require.Equal(t, uint64(3), app.GetCount())
+	require.Equal(t, hash, app.Hash())
+
+	err = app.UpdateStateImmediately(func(st metast.State) (metast.State, error) {
+		st.(*TestState).Number++
+		return st, nil
+	})
+	require.Equal(t, meta.ErrReadOnly, err)
+
+	// the database itself refuses to move the dataset's head
+	db, ds := app.GetDB(), app.GetDS()
+	_, err = db.CommitValue(ds, nt.String("overwritten"))
+	require.Equal(t, meta.ErrReadOnly, err)
+	_, err = db.SetHead(ds, ds.HeadRef())
+	require.Equal(t, meta.ErrReadOnly, err)
+	_, err = db.Delete(ds)
+	require.Equal(t, meta.ErrReadOnly, err)
+	require.Equal(t, hash, app.Hash())
+	require.Equal(t, ds.HeadRef(), db.GetDataset(ds.ID()).HeadRef())
+}
+
+func TestReadOnlyStorageMustExist(t *testing.T) {
+	config, cleanup := tempNBS(t)
+	cleanup()
+
+	config.ReadOnly = true
+	_, err := NewTestAppWithStorage(config)
+	require.Error(t, err)
+}
@@ -13,6 +13,7 @@ package testapp
 import (
 	"encoding/binary"
 	"fmt"
+	"time"
 
 	metatx "github.com/ndau/metanode/pkg/meta/transaction"
 )
@@ -22,6 +23,7 @@ import (
 // TxIDs is the testapp transactions
 var TxIDs = metatx.TxIDMap{
 	metatx.TxID(1): &Add{},
+	metatx.TxID(2): &SetHistorySize{},
 }
 
 // Add transactions add an appropriate amount to the state
@@ -54,3 +56,63 @@ func (a Add) SignableBytes() []byte {
 	binary.BigEndian.PutUint64(bytes, uint64(a.Qty))
 	return bytes
 }
+
+// SetHistorySize transactions are governance transactions which change
+// the vote statistics window size
+type SetHistorySize struct {
+	Size uint64
+}
+
+var _ metatx.Transactable = (*SetHistorySize)(nil)
+
+// Validate implements Transactable
+func (s SetHistorySize) Validate(interface{}) error {
+	if s.Size == 0 {
+		return fmt.Errorf("History size must be positive")
+	}
+	return nil
+}
+
+// Apply implements Transactable
+func (s SetHistorySize) Apply(appI interface{}) error {
+	app := appI.(*TestApp)
+	return app.SetVoteHistorySize(s.Size)
+}
+
+// SignableBytes implements Transactable
+func (s SetHistorySize) SignableBytes() []byte {
+	bytes := make([]byte, 8)
+	binary.BigEndian.PutUint64(bytes, s.Size)
+	return bytes
+}
+
+// Jitter transactions add the current time to the state
+//
+// They are deliberately nondeterministic, and so are not among TxIDs. They
+// exist to exercise the determinism check.
+type Jitter struct {
+	Scale uint64
+}
+
+var _ metatx.Transactable = (*Jitter)(nil)
+
+// Validate implements Transactable
+func (Jitter) Validate(interface{}) error {
+	return nil
+}
+
+// Apply implements Transactable
+func (j Jitter) Apply(appI interface{}) error {
+	app := appI.(*TestApp)
+	return app.UpdateCount(func(c *uint64) error {
+		*c += j.Scale * uint64(time.Now().UnixNano())
+		return nil
+	})
+}
+
+// SignableBytes implements Transactable
+func (j Jitter) SignableBytes() []byte {
+	bytes := make([]byte, 8)
+	binary.BigEndian.PutUint64(bytes, j.Scale)
+	return bytes
+}
@@ -0,0 +1,47 @@
+package testapp
+
+// ----- ---- --- -- -
+// Copyright 2019, 2020 The Axiom Foundation. All Rights Reserved.
+//
+// Licensed under the Apache License 2.0 (the "License").  You may not use
+// this file except in compliance with the License.  You can obtain a copy
+// in the file LICENSE in the source distribution or at
+// https://www.apache.org/licenses/LICENSE-2.0.txt
+// - -- --- ---- -----
+
+import (
+	"math/rand"
+	"testing"
+
+	metatx "github.com/ndau/metanode/pkg/meta/transaction"
+	"github.com/ndau/metanode/pkg/meta/transaction/txtest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransactionsConformance(t *testing.T) {
+	txtest.Kit{
+		TxIDs: TxIDs,
+		Generators: map[metatx.TxID]txtest.Generator{
+			1: func(r *rand.Rand) metatx.Transactable {
+				return &Add{Qty: r.Intn(2000) - 1000}
+			},
+			2: func(r *rand.Rand) metatx.Transactable {
+				return &SetHistorySize{Size: uint64(r.Intn(100))}
+			},
+		},
+		Fixture: &txtest.ApplyFixture{
+			New: func(t *testing.T) interface{} {
+				app, err := NewTestApp()
+				require.NoError(t, err)
+				return app
+			},
+			Snapshot: func(t *testing.T, appI interface{}) []byte {
+				app := appI.(*TestApp)
+				state, err := app.GetState().MarshalNoms(app.GetDB())
+				require.NoError(t, err)
+				h := state.Hash()
+				return h[:]
+			},
+		},
+	}.Run(t)
+}
@@ -0,0 +1,105 @@
+//go:build go1.18
+
+package testapp
+
+// ----- ---- --- -- -
+// Copyright 2019, 2020 The Axiom Foundation. All Rights Reserved.
+//
+// Licensed under the Apache License 2.0 (the "License").  You may not use
+// this file except in compliance with the License.  You can obtain a copy
+// in the file LICENSE in the source distribution or at
+// https://www.apache.org/licenses/LICENSE-2.0.txt
+// - -- --- ---- -----
+
+import (
+	"testing"
+
+	meta "github.com/ndau/metanode/pkg/meta/app"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+// otherState is a valid state which isn't the TestApp's
+type otherState struct {
+	TestState
+}
+
+func TestGetTyped(t *testing.T) {
+	app, err := NewTestApp()
+	require.NoError(t, err)
+
+	state, err := meta.GetTyped[*TestState](app.App)
+	require.NoError(t, err)
+	require.Equal(t, app.GetState(), state)
+
+	_, err = meta.GetTyped[*otherState](app.App)
+	require.Error(t, err)
+}
+
+func TestUpdateTyped(t *testing.T) {
+	app, err := NewTestApp()
+	require.NoError(t, err)
+
+	err = meta.UpdateTyped(app.App, func(state *TestState) (*TestState, error) {
+		state.Number = 5
+		return state, nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, uint64(5), app.GetCount())
+
+	// failed updates are discarded
+	err = meta.UpdateTyped(app.App, func(state *TestState) (*TestState, error) {
+		state.Number = 6
+		return state, errors.New("must discard changes now")
+	})
+	require.Error(t, err)
+	require.Equal(t, uint64(5), app.GetCount())
+
+	// nil typed states are caught
+	err = meta.UpdateTyped(app.App, func(*TestState) (*TestState, error) {
+		return nil, nil
+	})
+	require.Error(t, err)
+	require.Equal(t, uint64(5), app.GetCount())
+
+	// updaters of the wrong type are never called
+	called := false
+	err = meta.UpdateTyped(app.App, func(state *otherState) (*otherState, error) {
+		called = true
+		return state, nil
+	})
+	require.Error(t, err)
+	require.False(t, called)
+}
+
+func TestTypedApp(t *testing.T) {
+	app, err := NewTestApp()
+	require.NoError(t, err)
+
+	_, err = meta.NewTypedApp[*otherState](app.App)
+	require.Error(t, err)
+
+	typed, err := meta.NewTypedApp[*TestState](app.App)
+	require.NoError(t, err)
+
+	err = typed.Update(
+		func(state *TestState) (*TestState, error) {
+			state.Number++
+			return state, nil
+		},
+		func(state *TestState) (*TestState, error) {
+			state.Number *= 3
+			return state, nil
+		},
+	)
+	require.NoError(t, err)
+	require.Equal(t, uint64(3), uint64(typed.State().Number))
+	require.Equal(t, uint64(3), app.GetCount())
+
+	// the typed app shares the underlying app
+	require.NoError(t, app.UpdateCount(func(c *uint64) error {
+		*c = 10
+		return nil
+	}))
+	require.Equal(t, uint64(10), uint64(typed.State().Number))
+}
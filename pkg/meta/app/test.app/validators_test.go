@@ -0,0 +1,113 @@
+package testapp
+
+// ----- ---- --- -- -
+// Copyright 2019, 2020 The Axiom Foundation. All Rights Reserved.
+//
+// Licensed under the Apache License 2.0 (the "License").  You may not use
+// this file except in compliance with the License.  You can obtain a copy
+// in the file LICENSE in the source distribution or at
+// https://www.apache.org/licenses/LICENSE-2.0.txt
+// - -- --- ---- -----
+
+import (
+	"bytes"
+	"testing"
+
+	metast "github.com/ndau/metanode/pkg/meta/state"
+	"github.com/stretchr/testify/require"
+	abci "github.com/tendermint/tendermint/abci/types"
+	"github.com/tendermint/tendermint/crypto/ed25519"
+)
+
+func makeValUpdates(powers ...int64) []abci.ValidatorUpdate {
+	vus := make([]abci.ValidatorUpdate, 0, len(powers))
+	for _, power := range powers {
+		pub := ed25519.GenPrivKey().PubKey().(ed25519.PubKeyEd25519)
+		vus = append(vus, abci.Ed25519ValidatorUpdate(pub[:], power))
+	}
+	return vus
+}
+
+func TestValidatorsAreSorted(t *testing.T) {
+	app, err := NewTestApp()
+	require.NoError(t, err)
+
+	vus := makeValUpdates(5, 10, 5, 1)
+	app.InitChain(abci.RequestInitChain{Validators: vus})
+
+	vals, err := app.Validators()
+	require.NoError(t, err)
+	metast.ValidatorsAreEquivalent(t, metast.ValUpdatesToVals(t, vus), vals)
+
+	infos, err := app.ValidatorInfos()
+	require.NoError(t, err)
+	require.Len(t, infos, len(vus))
+	for i := 1; i < len(infos); i++ {
+		prev, cur := infos[i-1], infos[i]
+		require.True(t, prev.Power > cur.Power ||
+			(prev.Power == cur.Power && bytes.Compare(prev.Address, cur.Address) < 0))
+	}
+
+	// the pubkeys must agree with the addresses
+	for _, vi := range infos {
+		address, err := app.ValidatorAddress(vi.PubKey)
+		require.NoError(t, err)
+		require.Equal(t, vi.Address, address)
+	}
+}
+
+func TestValidatorAddressIndex(t *testing.T) {
+	for _, indexed := range []bool{false, true} {
+		app, err := NewTestApp()
+		require.NoError(t, err)
+
+		vus := makeValUpdates(1, 2)
+		app.InitChain(abci.RequestInitChain{Validators: vus[:1]})
+		if indexed {
+			require.NoError(t, app.IndexValidatorAddresses())
+		}
+		app.UpdateValidator(vus[1])
+
+		for _, vu := range vus {
+			address, err := app.ValidatorAddress(vu.PubKey)
+			require.NoError(t, err)
+			pk, found, err := app.ValidatorPubKey(address)
+			require.NoError(t, err)
+			require.True(t, found)
+			require.Equal(t, vu.PubKey, pk)
+		}
+
+		// remove a validator
+		address, err := app.ValidatorAddress(vus[0].PubKey)
+		require.NoError(t, err)
+		app.UpdateValidator(abci.ValidatorUpdate{PubKey: vus[0].PubKey, Power: 0})
+		_, found, err := app.ValidatorPubKey(address)
+		require.NoError(t, err)
+		// the index remembers former validators
+		require.Equal(t, indexed, found)
+	}
+}
+
+func TestValidatorAddressIndexIsPersisted(t *testing.T) {
+	app, err := NewTestApp()
+	require.NoError(t, err)
+
+	vus := makeValUpdates(3)
+	app.InitChain(abci.RequestInitChain{Validators: vus})
+	require.NoError(t, app.IndexValidatorAddresses())
+	require.NoError(t, app.UpdateStateImmediately(func(st metast.State) (metast.State, error) {
+		return st, nil
+	}))
+
+	ms := metast.Metastate{}
+	_, err = ms.Load(app.GetDB(), app.GetDS(), &TestState{})
+	require.NoError(t, err)
+	require.True(t, ms.IsIndexingValidatorAddresses())
+	require.Len(t, ms.GetValidatorAddresses(), 1)
+
+	// both directions of the index are persisted, and agree
+	require.Len(t, ms.GetValidatorPubKeys(), 1)
+	for pubkey, address := range ms.GetValidatorAddresses() {
+		require.Equal(t, pubkey, ms.GetValidatorPubKeys()[address])
+	}
+}
@@ -0,0 +1,97 @@
+package testapp
+
+// ----- ---- --- -- -
+// Copyright 2019, 2020 The Axiom Foundation. All Rights Reserved.
+//
+// Licensed under the Apache License 2.0 (the "License").  You may not use
+// this file except in compliance with the License.  You can obtain a copy
+// in the file LICENSE in the source distribution or at
+// https://www.apache.org/licenses/LICENSE-2.0.txt
+// - -- --- ---- -----
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"github.com/ndau/metanode/pkg/meta/app/apptest"
+	"github.com/ndau/metanode/pkg/meta/app/code"
+	metast "github.com/ndau/metanode/pkg/meta/state"
+	metatx "github.com/ndau/metanode/pkg/meta/transaction"
+	"github.com/stretchr/testify/require"
+)
+
+var voterAddress = []byte("01234567890123456789")
+
+func issueVotedBlock(chain *apptest.Chain, voted bool, txs ...metatx.Transactable) {
+	chain.SetVotes(apptest.Vote(voterAddress, 10, voted))
+	chain.Block(txs...)
+}
+
+func TestVoteHistoryDefaultSize(t *testing.T) {
+	app, err := NewTestApp()
+	require.NoError(t, err)
+	chain := apptest.NewChain(t, app.App, TxIDs)
+
+	for h := uint64(1); h <= 2*metast.HistorySize; h++ {
+		issueVotedBlock(chain, true)
+	}
+	require.Len(t, app.GetStats().History, metast.HistorySize)
+}
+
+func TestVoteHistorySizeGovernance(t *testing.T) {
+	app, err := NewTestApp()
+	require.NoError(t, err)
+	chain := apptest.NewChain(t, app.App, TxIDs)
+
+	for h := uint64(1); h <= 10; h++ {
+		issueVotedBlock(chain, true)
+	}
+	require.Len(t, app.GetStats().History, 10)
+
+	issueVotedBlock(chain, true, &SetHistorySize{Size: 3})
+	issueVotedBlock(chain, true)
+	require.Len(t, app.GetStats().History, 3)
+	require.Equal(t, uint64(11), app.GetStats().History[2].Height)
+
+	// zero-size windows are invalid
+	chain.CheckTx(apptest.Expect(code.InvalidTransaction, &SetHistorySize{}))
+}
+
+func TestVoteTotals(t *testing.T) {
+	app, err := NewTestApp()
+	require.NoError(t, err)
+	chain := apptest.NewChain(t, app.App, TxIDs)
+
+	// totals are not tracked by default
+	issueVotedBlock(chain, true)
+	require.Nil(t, app.GetVoteTotals())
+
+	app.TrackVoteTotals()
+	issueVotedBlock(chain, true, &SetHistorySize{Size: 1})
+	issueVotedBlock(chain, false)
+	issueVotedBlock(chain, true)
+
+	// the totals outlive the rolling window
+	require.Len(t, app.GetStats().History, 1)
+
+	addr := base64.StdEncoding.EncodeToString(voterAddress)
+	totals := app.GetVoteTotals()
+	require.Contains(t, totals, addr)
+	require.Equal(t, metast.VoteTotals{
+		Rounds:      3,
+		Voted:       2,
+		FirstHeight: 1,
+		LastHeight:  3,
+	}, totals[addr])
+
+	// the returned totals can't be used to alter the app's state
+	delete(totals, addr)
+	require.Contains(t, app.GetVoteTotals(), addr)
+	issueVotedBlock(chain, true)
+	require.Equal(t, metast.VoteTotals{
+		Rounds:      4,
+		Voted:       3,
+		FirstHeight: 1,
+		LastHeight:  4,
+	}, app.GetVoteTotals()[addr])
+}
@@ -0,0 +1,99 @@
+//go:build go1.18
+
+package app
+
+// ----- ---- --- -- -
+// Copyright 2019, 2020 The Axiom Foundation. All Rights Reserved.
+//
+// Licensed under the Apache License 2.0 (the "License").  You may not use
+// this file except in compliance with the License.  You can obtain a copy
+// in the file LICENSE in the source distribution or at
+// https://www.apache.org/licenses/LICENSE-2.0.txt
+// - -- --- ---- -----
+
+import (
+	"fmt"
+	"reflect"
+
+	metast "github.com/ndau/metanode/pkg/meta/state"
+	"github.com/pkg/errors"
+)
+
+// GetTyped returns the current child state as its concrete type
+//
+// S is typically a pointer type, i.e. `GetTyped[*MyState](app)`. It is an
+// error if the child state is not an S.
+func GetTyped[S metast.State](app *App) (S, error) {
+	state, ok := app.GetState().(S)
+	if !ok {
+		var zero S
+		return zero, fmt.Errorf("child state is %T, not %T", app.GetState(), zero)
+	}
+	return state, nil
+}
+
+// UpdateTyped is UpdateState for updaters of the concrete child state type
+//
+// It has the same semantics as UpdateState. It is an error if the child
+// state is not an S; in that case, no updater is called.
+func UpdateTyped[S metast.State](app *App, updaters ...func(state S) (S, error)) error {
+	if _, err := GetTyped[S](app); err != nil {
+		return errors.Wrap(err, "UpdateTyped")
+	}
+	return app.UpdateState(untypedUpdaters(updaters)...)
+}
+
+// untypedUpdaters wraps typed updaters in the form UpdateState expects
+func untypedUpdaters[S metast.State](updaters []func(state S) (S, error)) []func(metast.State) (metast.State, error) {
+	wrapped := make([]func(metast.State) (metast.State, error), 0, len(updaters))
+	for _, updater := range updaters {
+		updater := updater
+		wrapped = append(wrapped, func(st metast.State) (metast.State, error) {
+			// UpdateState ensures that the state passed to each updater
+			// has the type of the child state, or is returned by a
+			// previous updater
+			next, err := updater(st.(S))
+			if err != nil {
+				return nil, err
+			}
+			// a nil *MyState is a non-nil metast.State, which UpdateState
+			// wouldn't catch
+			if v := reflect.ValueOf(next); !v.IsValid() || (v.Kind() == reflect.Ptr && v.IsNil()) {
+				return nil, errors.New("nil state returned from UpdateTyped")
+			}
+			return next, nil
+		})
+	}
+	return wrapped
+}
+
+// TypedApp wraps an App whose child state is known to be an S
+//
+// The child state's type is checked once, in NewTypedApp, so that the
+// methods of TypedApp can't fail due to a type mismatch. Child apps may embed
+// a TypedApp instead of a bare *App:
+//
+//	type MyApp struct {
+//	    meta.TypedApp[*MyState]
+//	}
+type TypedApp[S metast.State] struct {
+	*App
+}
+
+// NewTypedApp wraps an App whose child state is an S
+func NewTypedApp[S metast.State](app *App) (TypedApp[S], error) {
+	if _, err := GetTyped[S](app); err != nil {
+		return TypedApp[S]{}, errors.Wrap(err, "NewTypedApp")
+	}
+	return TypedApp[S]{App: app}, nil
+}
+
+// State returns the current child state
+func (t TypedApp[S]) State() S {
+	return t.App.GetState().(S)
+}
+
+// Update is UpdateState for updaters of the concrete child state type
+func (t TypedApp[S]) Update(updaters ...func(state S) (S, error)) error {
+	return t.App.UpdateState(untypedUpdaters(updaters)...)
+}
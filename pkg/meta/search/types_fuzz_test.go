@@ -0,0 +1,55 @@
+//go:build go1.18
+
+package search
+
+// ----- ---- --- -- -
+// Copyright 2019, 2020 The Axiom Foundation. All Rights Reserved.
+//
+// Licensed under the Apache License 2.0 (the "License").  You may not use
+// this file except in compliance with the License.  You can obtain a copy
+// in the file LICENSE in the source distribution or at
+// https://www.apache.org/licenses/LICENSE-2.0.txt
+// - -- --- ---- -----
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func FuzzDateRangeRequest(f *testing.F) {
+	f.Add("2019-01-01T00:00:00Z 2019-12-31T23:59:59Z")
+	f.Add(" ")
+	f.Add("noseparator")
+	f.Add("a b c")
+
+	f.Fuzz(func(t *testing.T, s string) {
+		request := DateRangeRequest{}
+		err := request.Unmarshal(s)
+		if err != nil {
+			return
+		}
+		// the request format is lossless
+		require.Equal(t, s, request.Marshal())
+	})
+}
+
+func FuzzDateRangeResult(f *testing.F) {
+	f.Add("1 2")
+	f.Add("0 18446744073709551615")
+	f.Add("18446744073709551616 0")
+	f.Add("-1 1")
+	f.Add("1  2")
+
+	f.Fuzz(func(t *testing.T, s string) {
+		result := DateRangeResult{}
+		err := result.Unmarshal(s)
+		if err != nil {
+			return
+		}
+		// heights may have been written non-canonically, but must round trip
+		again := DateRangeResult{}
+		require.NoError(t, again.Unmarshal(result.Marshal()))
+		require.Equal(t, result, again)
+	})
+}
@@ -31,3 +31,17 @@ type State interface {
 	// initialize maps etc as required.
 	Init(vrw nt.ValueReadWriter)
 }
+
+// A Cloner is a State which can make a deep copy of itself.
+//
+// Implementing Cloner is optional. States which don't implement it are
+// copied by a noms marshal/unmarshal round trip, which is correct but may be
+// slow for large states.
+type Cloner interface {
+	State
+
+	// Clone returns a deep copy of the state: no map, slice, or pointer
+	// in the copy may alias the original. The copy must have the same
+	// concrete type as the original.
+	Clone() State
+}
@@ -0,0 +1,165 @@
+package state
+
+// ----- ---- --- -- -
+// Copyright 2019, 2020 The Axiom Foundation. All Rights Reserved.
+//
+// Licensed under the Apache License 2.0 (the "License").  You may not use
+// this file except in compliance with the License.  You can obtain a copy
+// in the file LICENSE in the source distribution or at
+// https://www.apache.org/licenses/LICENSE-2.0.txt
+// - -- --- ---- -----
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+	abci "github.com/tendermint/tendermint/abci/types"
+)
+
+// generate noms marshalers
+//nomsify ConsensusParams ScheduledConsensusParams
+
+// ConsensusParams are the tendermint consensus parameters managed by the metastate
+//
+// This is a flattened version of abci.ConsensusParams, because nomsify can't
+// handle pointers or durations.
+type ConsensusParams struct {
+	BlockMaxBytes           int64
+	BlockMaxGas             int64
+	EvidenceMaxAgeNumBlocks int64
+	// EvidenceMaxAgeDuration is measured in nanoseconds
+	EvidenceMaxAgeDuration int64
+	ValidatorPubKeyTypes   []string
+}
+
+// ScheduledConsensusParams are consensus params which take effect at the
+// EndBlock of a particular height
+type ScheduledConsensusParams struct {
+	Height uint64
+	Params ConsensusParams
+}
+
+// ConsensusParamsFrom converts tendermint's consensus params
+//
+// Missing sections are left zeroed.
+func ConsensusParamsFrom(cp *abci.ConsensusParams) ConsensusParams {
+	out := ConsensusParams{}
+	if cp == nil {
+		return out
+	}
+	if cp.Block != nil {
+		out.BlockMaxBytes = cp.Block.MaxBytes
+		out.BlockMaxGas = cp.Block.MaxGas
+	}
+	if cp.Evidence != nil {
+		out.EvidenceMaxAgeNumBlocks = cp.Evidence.MaxAgeNumBlocks
+		out.EvidenceMaxAgeDuration = int64(cp.Evidence.MaxAgeDuration)
+	}
+	if cp.Validator != nil {
+		out.ValidatorPubKeyTypes = append([]string{}, cp.Validator.PubKeyTypes...)
+	}
+	return out
+}
+
+// ABCI converts these params into tendermint's representation
+func (cp ConsensusParams) ABCI() *abci.ConsensusParams {
+	return &abci.ConsensusParams{
+		Block: &abci.BlockParams{
+			MaxBytes: cp.BlockMaxBytes,
+			MaxGas:   cp.BlockMaxGas,
+		},
+		Evidence: &abci.EvidenceParams{
+			MaxAgeNumBlocks: cp.EvidenceMaxAgeNumBlocks,
+			MaxAgeDuration:  time.Duration(cp.EvidenceMaxAgeDuration),
+		},
+		Validator: &abci.ValidatorParams{
+			PubKeyTypes: append([]string{}, cp.ValidatorPubKeyTypes...),
+		},
+	}
+}
+
+// Validate returns an error if tendermint would reject these params
+func (cp ConsensusParams) Validate() error {
+	if cp.BlockMaxBytes <= 0 {
+		return fmt.Errorf("BlockMaxBytes must be positive; got %d", cp.BlockMaxBytes)
+	}
+	if cp.BlockMaxGas < -1 {
+		return fmt.Errorf("BlockMaxGas must be -1 or greater; got %d", cp.BlockMaxGas)
+	}
+	if cp.EvidenceMaxAgeNumBlocks <= 0 {
+		return fmt.Errorf("EvidenceMaxAgeNumBlocks must be positive; got %d", cp.EvidenceMaxAgeNumBlocks)
+	}
+	if cp.EvidenceMaxAgeDuration <= 0 {
+		return fmt.Errorf("EvidenceMaxAgeDuration must be positive; got %d", cp.EvidenceMaxAgeDuration)
+	}
+	if len(cp.ValidatorPubKeyTypes) == 0 {
+		return errors.New("ValidatorPubKeyTypes must not be empty")
+	}
+	return nil
+}
+
+// TrackConsensusParams begins persisting the consensus params in the metastate
+//
+// As with the other optional metastate fields, this is opt-in so that existing
+// chains can replay their history. To capture the genesis params, it must be
+// called before InitChain. It is a no-op if the params are already tracked.
+func (m *Metastate) TrackConsensusParams() {
+	if !m.IsTrackingConsensusParams() {
+		m.SetConsensusParams(ConsensusParams{})
+	}
+}
+
+// IsTrackingConsensusParams is true when the consensus params are persisted
+func (m *Metastate) IsTrackingConsensusParams() bool {
+	_, ok := m.managedVars["ConsensusParams"]
+	return ok
+}
+
+// ScheduleConsensusParams schedules the given params to take effect at the given height
+//
+// Scheduling params for a height which already has scheduled params replaces them.
+func (m *Metastate) ScheduleConsensusParams(height uint64, cp ConsensusParams) error {
+	if !m.IsTrackingConsensusParams() {
+		return errors.New("consensus params are not tracked")
+	}
+	if height < m.Height {
+		return fmt.Errorf("can't schedule consensus params for past height %d (current %d)", height, m.Height)
+	}
+	if err := cp.Validate(); err != nil {
+		return errors.Wrap(err, "invalid consensus params")
+	}
+
+	scheduled := make([]ScheduledConsensusParams, 0, len(m.GetScheduledConsensusParams())+1)
+	for _, s := range m.GetScheduledConsensusParams() {
+		if s.Height != height {
+			scheduled = append(scheduled, s)
+		}
+	}
+	scheduled = append(scheduled, ScheduledConsensusParams{Height: height, Params: cp})
+	sort.Slice(scheduled, func(i, j int) bool {
+		return scheduled[i].Height < scheduled[j].Height
+	})
+	m.SetScheduledConsensusParams(scheduled)
+	return nil
+}
+
+// ApplyScheduledConsensusParams applies all consensus params scheduled at or
+// before the given height
+//
+// It returns the params which are now in effect, or nil if nothing changed.
+func (m *Metastate) ApplyScheduledConsensusParams(height uint64) *ConsensusParams {
+	scheduled := m.GetScheduledConsensusParams()
+	idx := 0
+	for idx < len(scheduled) && scheduled[idx].Height <= height {
+		idx++
+	}
+	if idx == 0 {
+		return nil
+	}
+	cp := scheduled[idx-1].Params
+	m.SetConsensusParams(cp)
+	m.SetScheduledConsensusParams(scheduled[idx:])
+	return &cp
+}
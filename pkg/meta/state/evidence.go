@@ -0,0 +1,93 @@
+package state
+
+// ----- ---- --- -- -
+// Copyright 2019, 2020 The Axiom Foundation. All Rights Reserved.
+//
+// Licensed under the Apache License 2.0 (the "License").  You may not use
+// this file except in compliance with the License.  You can obtain a copy
+// in the file LICENSE in the source distribution or at
+// https://www.apache.org/licenses/LICENSE-2.0.txt
+// - -- --- ---- -----
+
+import (
+	"encoding/base64"
+
+	abci "github.com/tendermint/tendermint/abci/types"
+)
+
+// generate noms marshalers
+//nomsify Evidence
+
+// Evidence is a persistent record of a single piece of byzantine evidence
+// presented by tendermint
+type Evidence struct {
+	// Type is the tendermint evidence type, i.e. "duplicate/vote"
+	Type string
+	// Validator is the base64 address of the offending validator
+	Validator string
+	// Power is the validator's voting power at the time of the offense
+	Power int64
+	// Height is the height at which the offense occurred
+	Height uint64
+	// ReportedHeight is the height of the block which presented the evidence
+	ReportedHeight uint64
+}
+
+// MakeEvidence converts tendermint's evidence into an Evidence record
+func MakeEvidence(ev abci.Evidence, reportedHeight uint64) Evidence {
+	return Evidence{
+		Type:           ev.Type,
+		Validator:      base64.StdEncoding.EncodeToString(ev.Validator.Address),
+		Power:          ev.Validator.Power,
+		Height:         uint64(ev.Height),
+		ReportedHeight: reportedHeight,
+	}
+}
+
+// TrackEvidence begins persisting byzantine evidence in the metastate
+//
+// As with TrackVoteTotals, this is opt-in so that existing chains can replay
+// their history. It is a no-op if evidence is already tracked.
+func (m *Metastate) TrackEvidence() {
+	if !m.IsTrackingEvidence() {
+		m.SetEvidence(make([]Evidence, 0))
+	}
+}
+
+// IsTrackingEvidence is true when byzantine evidence is persisted
+func (m *Metastate) IsTrackingEvidence() bool {
+	_, ok := m.managedVars["Evidence"]
+	return ok
+}
+
+// RecordEvidence converts all the byzantine evidence in the request
+//
+// It returns the evidence in the order tendermint presented it. If evidence is
+// tracked, it is also persisted in the metastate. Evidence is recorded whether
+// or not the validator is in the current validator set, and whether or not it
+// is historical.
+func (m *Metastate) RecordEvidence(req abci.RequestBeginBlock) []Evidence {
+	if len(req.ByzantineValidators) == 0 {
+		return nil
+	}
+	recorded := make([]Evidence, 0, len(req.ByzantineValidators))
+	for _, ev := range req.ByzantineValidators {
+		recorded = append(recorded, MakeEvidence(ev, uint64(req.Header.Height)))
+	}
+	if m.IsTrackingEvidence() {
+		m.SetEvidence(append(m.GetEvidence(), recorded...))
+	}
+	return recorded
+}
+
+// EvidenceFor returns all recorded evidence against the validator with the
+// given base64 address
+func (m *Metastate) EvidenceFor(address string) []Evidence {
+	var out []Evidence
+	for _, ev := range m.GetEvidence() {
+		if ev.Validator == address {
+			out = append(out, ev)
+		}
+	}
+	return out
+}
@@ -0,0 +1,112 @@
+package state
+
+// ----- ---- --- -- -
+// Copyright 2019, 2020 The Axiom Foundation. All Rights Reserved.
+//
+// Licensed under the Apache License 2.0 (the "License").  You may not use
+// this file except in compliance with the License.  You can obtain a copy
+// in the file LICENSE in the source distribution or at
+// https://www.apache.org/licenses/LICENSE-2.0.txt
+// - -- --- ---- -----
+
+// GenesisMetastate is the portion of the metastate which is carried through
+// a genesis export: its managed variables.
+//
+// The validator set is exported separately, as tendermint genesis validators.
+// A nil field corresponds to a managed variable which was never set; as
+// setting a managed variable changes the app hash, the distinction between
+// unset and empty is preserved.
+type GenesisMetastate struct {
+	VoteHistorySize          *uint64                    `json:"vote_history_size"`
+	VoteTotals               map[string]VoteTotals      `json:"vote_totals"`
+	Evidence                 []Evidence                 `json:"evidence"`
+	ValidatorAddresses       map[string]string          `json:"validator_addresses"`
+	ValidatorPubKeys         map[string]string          `json:"validator_pubkeys"`
+	ConsensusParams          *ConsensusParams           `json:"consensus_params"`
+	ScheduledConsensusParams []ScheduledConsensusParams `json:"scheduled_consensus_params"`
+	// SchemaVersion must be carried with the child state: a chain restarted
+	// with an already-migrated child state must not migrate it again
+	SchemaVersion *uint64 `json:"schema_version"`
+}
+
+func (m *Metastate) isSet(managedVar string) bool {
+	_, ok := m.managedVars[managedVar]
+	return ok
+}
+
+// ExportGenesis returns the managed variables of the metastate
+func (m *Metastate) ExportGenesis() GenesisMetastate {
+	var g GenesisMetastate
+	if m.isSet("VoteHistorySize") {
+		size := m.GetVoteHistorySize()
+		g.VoteHistorySize = &size
+	}
+	if m.isSet("VoteTotals") {
+		g.VoteTotals = make(map[string]VoteTotals, len(m.managedVarVoteTotals))
+		for addr, vt := range m.managedVarVoteTotals {
+			g.VoteTotals[addr] = vt
+		}
+	}
+	if m.isSet("Evidence") {
+		g.Evidence = append(make([]Evidence, 0, len(m.managedVarEvidence)), m.managedVarEvidence...)
+	}
+	if m.isSet("ValidatorAddresses") {
+		g.ValidatorAddresses = copyStringMap(m.managedVarValidatorAddresses)
+	}
+	if m.isSet("ValidatorPubKeys") {
+		g.ValidatorPubKeys = copyStringMap(m.managedVarValidatorPubKeys)
+	}
+	if m.isSet("ConsensusParams") {
+		cp := m.GetConsensusParams()
+		g.ConsensusParams = &cp
+	}
+	if m.isSet("ScheduledConsensusParams") {
+		g.ScheduledConsensusParams = append(
+			make([]ScheduledConsensusParams, 0, len(m.managedVarScheduledConsensusParams)),
+			m.managedVarScheduledConsensusParams...,
+		)
+	}
+	if m.isSet("SchemaVersion") {
+		version := m.GetSchemaVersion()
+		g.SchemaVersion = &version
+	}
+	return g
+}
+
+// ImportGenesis sets the managed variables of the metastate from an export
+//
+// Managed variables which are nil in the export are left as they are.
+func (m *Metastate) ImportGenesis(g GenesisMetastate) {
+	if g.VoteHistorySize != nil {
+		m.SetVoteHistorySize(*g.VoteHistorySize)
+	}
+	if g.VoteTotals != nil {
+		m.SetVoteTotals(g.VoteTotals)
+	}
+	if g.Evidence != nil {
+		m.SetEvidence(g.Evidence)
+	}
+	if g.ValidatorAddresses != nil {
+		m.SetValidatorAddresses(g.ValidatorAddresses)
+	}
+	if g.ValidatorPubKeys != nil {
+		m.SetValidatorPubKeys(g.ValidatorPubKeys)
+	}
+	if g.ConsensusParams != nil {
+		m.SetConsensusParams(*g.ConsensusParams)
+	}
+	if g.ScheduledConsensusParams != nil {
+		m.SetScheduledConsensusParams(g.ScheduledConsensusParams)
+	}
+	if g.SchemaVersion != nil {
+		m.SetSchemaVersion(*g.SchemaVersion)
+	}
+}
+
+func copyStringMap(m map[string]string) map[string]string {
+	out := make(map[string]string, len(m))
+	for k, v := range m {
+		out[k] = v
+	}
+	return out
+}
@@ -71,6 +71,21 @@ func IterHistory(
 	db datas.Database, ds datas.Dataset,
 	example State,
 	cb func(state State, height uint64) error,
+) error {
+	return IterMetastates(db, ds, example, func(metastate Metastate) error {
+		return cb(metastate.ChildState, uint64(metastate.Height))
+	})
+}
+
+// IterMetastates is like IterHistory, but the callback receives the full
+// metastate at each point in history.
+//
+// The same cautions apply. Note also that every metastate's ChildState
+// is the example: it is overwritten on each iteration.
+func IterMetastates(
+	db datas.Database, ds datas.Dataset,
+	example State,
+	cb func(metastate Metastate) error,
 ) error {
 	headRef, hasHead := ds.MaybeHeadRef()
 	for hasHead {
@@ -81,7 +96,7 @@ func IterHistory(
 		}
 
 		// call the callback
-		err = cb(metastate.ChildState, uint64(metastate.Height))
+		err = cb(metastate)
 		if err != nil {
 			if IsStopIteration(err) {
 				return nil
@@ -103,6 +118,28 @@ func IterHistory(
 	return nil
 }
 
+// VerifyHistory checks the invariant on which IterHistory depends
+//
+// It walks back from the current head of the dataset to its first commit,
+// and returns the number of commits visited. It is an error for any commit
+// to have more than one parent.
+func VerifyHistory(db datas.Database, ds datas.Dataset) (commits uint64, err error) {
+	headRef, hasHead := ds.MaybeHeadRef()
+	for hasHead {
+		commits++
+		parent, err := parentOf(db, headRef)
+		if err != nil {
+			return commits, errors.Wrapf(err, "commit %s", headRef.TargetHash())
+		}
+		if parent == nil {
+			hasHead = false
+		} else {
+			headRef = *parent
+		}
+	}
+	return commits, nil
+}
+
 // AtHeight retrieves the state as of a given tendermint height and puts it into
 // the provided State object.
 //
@@ -116,34 +153,46 @@ func AtHeight(
 	state State,
 	wantHeight uint64,
 ) error {
+	_, err := MetastateAtHeight(db, ds, state, wantHeight)
+	return err
+}
+
+// MetastateAtHeight retrieves the full metastate as of a given tendermint height.
+//
+// The child state is unmarshaled into the provided State object, which
+// is also the ChildState of the returned metastate. Runtime is as for AtHeight.
+func MetastateAtHeight(
+	db datas.Database, ds datas.Dataset,
+	state State,
+	wantHeight uint64,
+) (Metastate, error) {
 	headRef, hasHead := ds.MaybeHeadRef()
 	if !hasHead {
-		return errors.New("AtHeight: No head in this dataset")
+		return Metastate{}, errors.New("AtHeight: No head in this dataset")
 	}
 	metastate, err := metastateAt(db, headRef, state)
 	if err != nil {
-		return err
+		return metastate, err
 	}
 	if wantHeight > uint64(metastate.Height) {
-		return errors.New("Requested height higher than current head")
+		return metastate, errors.New("Requested height higher than current head")
 	} else if wantHeight == 0 || wantHeight == uint64(metastate.Height) {
-		state = metastate.ChildState
-		return nil
+		return metastate, nil
 	}
 
-	err = IterHistory(db, ds, state, func(hstate State, height uint64) error {
-		// IterHistory iterates backwards down heights, and doesn't iterate
+	err = IterMetastates(db, ds, state, func(hmetastate Metastate) error {
+		// IterMetastates iterates backwards down heights, and doesn't iterate
 		// over any heights for which no transactions occurred. Therefore,
 		// the correct state is the _first_ in this backwards iteration for
 		// which the height <= the desired height
-		if height <= wantHeight {
-			state = hstate
+		metastate = hmetastate
+		if hmetastate.Height <= wantHeight {
 			return StopIteration()
 		}
 		return nil
 	})
 	// errors.Wrap returns nil if err == nil
-	return errors.Wrap(err, "AtHeight failed iterating history")
+	return metastate, errors.Wrap(err, "AtHeight failed iterating history")
 }
 
 func metastateAt(db datas.Database, ref nt.Ref, example State) (Metastate, error) {
@@ -0,0 +1,222 @@
+package state
+
+// ----- ---- --- -- -
+// Copyright 2019, 2020 The Axiom Foundation. All Rights Reserved.
+//
+// Licensed under the Apache License 2.0 (the "License").  You may not use
+// this file except in compliance with the License.  You can obtain a copy
+// in the file LICENSE in the source distribution or at
+// https://www.apache.org/licenses/LICENSE-2.0.txt
+// - -- --- ---- -----
+
+import (
+	"fmt"
+
+	"github.com/ndau/noms/go/datas"
+	"github.com/ndau/noms/go/hash"
+	nt "github.com/ndau/noms/go/types"
+	"github.com/pkg/errors"
+)
+
+// An IntegrityProblem is a defect of a single commit
+type IntegrityProblem struct {
+	Commit hash.Hash
+	// Height is the tendermint height of the commit's metastate, if it
+	// could be read
+	Height  uint64
+	Problem string
+}
+
+// String implements fmt.Stringer
+func (p IntegrityProblem) String() string {
+	return fmt.Sprintf("commit %s (height %d): %s", p.Commit, p.Height, p.Problem)
+}
+
+// An IntegrityReport is the result of CheckIntegrity
+type IntegrityReport struct {
+	// Commits is the number of commits checked
+	Commits uint64
+	// Problems are ordered from the newest commit to the oldest
+	Problems []IntegrityProblem
+	// LastGood is the newest commit which, along with all of its ancestors,
+	// passed every check. It is nil if there is no such commit.
+	LastGood *nt.Ref
+	// LastGoodHeight is the tendermint height of LastGood's metastate
+	LastGoodHeight uint64
+}
+
+// OK is true when no problems were found
+func (r IntegrityReport) OK() bool {
+	return len(r.Problems) == 0
+}
+
+// CheckIntegrity walks back through every commit of the dataset, checking
+// that:
+//
+//   - the commit can be read, and its contents match its hash
+//   - the commit has at most one parent, as IterHistory requires
+//   - the commit's value can be unmarshaled into a Metastate whose child
+//     state is the example
+//   - heights never decrease from a commit to its child
+//
+// Missing commits and commits with several parents end the walk, as their
+// ancestry can't be followed.
+//
+// Errors are returned only if the check itself could not be performed; all
+// defects of the dataset are reported as problems.
+func CheckIntegrity(db datas.Database, ds datas.Dataset, example State) (report IntegrityReport, err error) {
+	if db == nil || example == nil {
+		return report, errors.New("CheckIntegrity: db and example must not be nil")
+	}
+
+	// newer is the check of the previously checked commit: this commit's child
+	var newer *commitCheck
+	ref, hasRef := ds.MaybeHeadRef()
+	for hasRef {
+		report.Commits++
+		check := checkCommit(db, ref, example)
+
+		// heights are compared at the parent, but the child takes the blame
+		if newer != nil && newer.hasHeight && check.hasHeight && newer.height < check.height {
+			report.Problems = append(report.Problems, IntegrityProblem{
+				Commit:  newer.commit,
+				Height:  newer.height,
+				Problem: fmt.Sprintf("height decreased from its parent's height %d", check.height),
+			})
+			report.LastGood = nil
+		}
+
+		for _, problem := range check.problems {
+			report.Problems = append(report.Problems, IntegrityProblem{
+				Commit:  check.commit,
+				Height:  check.height,
+				Problem: problem,
+			})
+		}
+		if len(check.problems) > 0 || check.stop {
+			// every commit since this one is descended from a bad commit
+			report.LastGood = nil
+		} else if report.LastGood == nil {
+			good := ref
+			report.LastGood = &good
+			report.LastGoodHeight = check.height
+		}
+
+		if check.stop {
+			break
+		}
+		newer = &check
+		hasRef = check.parent != nil
+		if hasRef {
+			ref = *check.parent
+		}
+	}
+	return report, nil
+}
+
+// commitCheck is the result of checking a single commit
+type commitCheck struct {
+	commit hash.Hash
+	// height is the height of the commit's metastate, if hasHeight
+	height    uint64
+	hasHeight bool
+	parent    *nt.Ref
+	// stop is set if the commit's ancestry can't be followed
+	stop     bool
+	problems []string
+}
+
+func checkCommit(db datas.Database, ref nt.Ref, example State) (check commitCheck) {
+	check.commit = ref.TargetHash()
+	fail := func(problem string) commitCheck {
+		check.problems = append(check.problems, problem)
+		return check
+	}
+
+	var commit nt.Value
+	err := recoverNoms(func() error {
+		commit = ref.TargetValue(db)
+		return nil
+	})
+	if err != nil {
+		check.stop = true
+		return fail("reading commit: " + err.Error())
+	}
+	if commit == nil {
+		check.stop = true
+		return fail("commit is missing from the database: was it partially written?")
+	}
+	if commit.Hash() != check.commit {
+		check.problems = append(check.problems, fmt.Sprintf("hash mismatch: contents hash to %s", commit.Hash()))
+	}
+	commitS, ok := commit.(nt.Struct)
+	if !ok {
+		check.stop = true
+		return fail("commit is not a struct")
+	}
+
+	parentsV, ok := commitS.MaybeGet(datas.ParentsField)
+	parents, isSet := parentsV.(nt.Set)
+	switch {
+	case !ok || !isSet:
+		check.stop = true
+		return fail("commit has no parent set")
+	case parents.First() == nil:
+		// this is the first commit
+	case !setSizeEq1(parents):
+		check.stop = true
+		return fail("more than 1 commit parent found")
+	default:
+		parent, isRef := parents.First().(nt.Ref)
+		if !isRef {
+			check.stop = true
+			return fail("commit parent is not a ref")
+		}
+		check.parent = &parent
+	}
+
+	value, ok := commitS.MaybeGet(datas.ValueField)
+	if !ok {
+		return fail("commit has no value")
+	}
+	metastate := newMetaState(db, example)
+	err = recoverNoms(func() error {
+		return metastate.UnmarshalNoms(value)
+	})
+	if err != nil {
+		return fail("unmarshaling metastate: " + err.Error())
+	}
+	check.height = metastate.Height
+	check.hasHeight = true
+	return check
+}
+
+// recoverNoms converts noms' panics, which it raises when data is corrupt,
+// into errors
+func recoverNoms(f func() error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("noms panicked: %v", r)
+		}
+	}()
+	return f()
+}
+
+// RewindToLastGood sets the head of the dataset to the last good commit of
+// the report
+//
+// Nothing is deleted: the bad commits remain in the database, but are no
+// longer reachable from the dataset. The report must have been produced
+// by CheckIntegrity for this dataset, and any App using the dataset must be
+// reloaded afterwards. If the report found no problems, the dataset is
+// returned unchanged.
+func RewindToLastGood(db datas.Database, ds datas.Dataset, report IntegrityReport) (datas.Dataset, error) {
+	if report.OK() {
+		return ds, nil
+	}
+	if report.LastGood == nil {
+		return ds, errors.New("RewindToLastGood: the dataset has no good commit")
+	}
+	ds, err := db.SetHead(ds, *report.LastGood)
+	return ds, errors.Wrap(err, "RewindToLastGood")
+}
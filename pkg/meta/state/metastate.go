@@ -27,6 +27,19 @@ type Metastate struct {
 	Height     uint64
 	Stats      VoteStats
 	ChildState State
+
+	managedVars               map[string]struct{}
+	managedVarVoteHistorySize uint64
+	managedVarVoteTotals      map[string]VoteTotals
+	managedVarEvidence        []Evidence
+
+	managedVarValidatorAddresses map[string]string
+	managedVarValidatorPubKeys   map[string]string
+
+	managedVarConsensusParams          ConsensusParams
+	managedVarScheduledConsensusParams []ScheduledConsensusParams
+
+	managedVarSchemaVersion uint64
 }
 
 const metastateName = "metastate"
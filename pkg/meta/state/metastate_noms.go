@@ -95,7 +95,104 @@ func (x Metastate) MarshalNoms(vrw nt.ValueReadWriter) (metastateValue nt.Value,
 		nt.NewMap(vrw, validatorsKVs...),
 	}
 
-	return metastateStructTemplate.NewStruct(values), nil
+	metastateStruct := metastateStructTemplate.NewStruct(values)
+
+	// managed vars are only marshaled once they have been set, so that
+	// adding one never changes the hash of a previously-committed metastate
+	if _, ok := x.managedVars["VoteHistorySize"]; ok {
+		metastateStruct = metastateStruct.Set(
+			"VoteHistorySize",
+			util.Int(x.managedVarVoteHistorySize).NomsValue(),
+		)
+	}
+	if _, ok := x.managedVars["VoteTotals"]; ok {
+		// template map: x.managedVarVoteTotals
+		voteTotalsKVs := make([]nt.Value, 0, len(x.managedVarVoteTotals)*2)
+		for voteTotalsKey, voteTotalsValue := range x.managedVarVoteTotals {
+			// template nomsmarshaler: voteTotalsValue
+			voteTotalsValueValue, err := voteTotalsValue.MarshalNoms(vrw)
+			if err != nil {
+				return nil, errors.Wrap(err, "Metastate.MarshalNoms->voteTotalsValue.MarshalNoms")
+			}
+			voteTotalsKVs = append(
+				voteTotalsKVs,
+				nt.String(voteTotalsKey),
+				voteTotalsValueValue,
+			)
+		}
+		metastateStruct = metastateStruct.Set("VoteTotals", nt.NewMap(vrw, voteTotalsKVs...))
+	}
+
+	if _, ok := x.managedVars["Evidence"]; ok {
+		// template slice: x.managedVarEvidence
+		evidenceItems := make([]nt.Value, 0, len(x.managedVarEvidence))
+		for _, evidenceItem := range x.managedVarEvidence {
+			// template nomsmarshaler: evidenceItem
+			evidenceItemValue, err := evidenceItem.MarshalNoms(vrw)
+			if err != nil {
+				return nil, errors.Wrap(err, "Metastate.MarshalNoms->evidenceItem.MarshalNoms")
+			}
+			evidenceItems = append(evidenceItems, evidenceItemValue)
+		}
+		metastateStruct = metastateStruct.Set("Evidence", nt.NewList(vrw, evidenceItems...))
+	}
+
+	if _, ok := x.managedVars["ValidatorAddresses"]; ok {
+		// template map: x.managedVarValidatorAddresses
+		validatorAddressesKVs := make([]nt.Value, 0, len(x.managedVarValidatorAddresses)*2)
+		for validatorAddressesKey, validatorAddressesValue := range x.managedVarValidatorAddresses {
+			validatorAddressesKVs = append(
+				validatorAddressesKVs,
+				nt.String(validatorAddressesKey),
+				nt.String(validatorAddressesValue),
+			)
+		}
+		metastateStruct = metastateStruct.Set("ValidatorAddresses", nt.NewMap(vrw, validatorAddressesKVs...))
+	}
+
+	if _, ok := x.managedVars["ValidatorPubKeys"]; ok {
+		// template map: x.managedVarValidatorPubKeys
+		validatorPubKeysKVs := make([]nt.Value, 0, len(x.managedVarValidatorPubKeys)*2)
+		for validatorPubKeysKey, validatorPubKeysValue := range x.managedVarValidatorPubKeys {
+			validatorPubKeysKVs = append(
+				validatorPubKeysKVs,
+				nt.String(validatorPubKeysKey),
+				nt.String(validatorPubKeysValue),
+			)
+		}
+		metastateStruct = metastateStruct.Set("ValidatorPubKeys", nt.NewMap(vrw, validatorPubKeysKVs...))
+	}
+
+	if _, ok := x.managedVars["ConsensusParams"]; ok {
+		// template nomsmarshaler: x.managedVarConsensusParams
+		consensusParamsValue, err := x.managedVarConsensusParams.MarshalNoms(vrw)
+		if err != nil {
+			return nil, errors.Wrap(err, "Metastate.MarshalNoms->ConsensusParams.MarshalNoms")
+		}
+		metastateStruct = metastateStruct.Set("ConsensusParams", consensusParamsValue)
+	}
+	if _, ok := x.managedVars["ScheduledConsensusParams"]; ok {
+		// template slice: x.managedVarScheduledConsensusParams
+		scheduledItems := make([]nt.Value, 0, len(x.managedVarScheduledConsensusParams))
+		for _, scheduledItem := range x.managedVarScheduledConsensusParams {
+			// template nomsmarshaler: scheduledItem
+			scheduledItemValue, err := scheduledItem.MarshalNoms(vrw)
+			if err != nil {
+				return nil, errors.Wrap(err, "Metastate.MarshalNoms->scheduledItem.MarshalNoms")
+			}
+			scheduledItems = append(scheduledItems, scheduledItemValue)
+		}
+		metastateStruct = metastateStruct.Set("ScheduledConsensusParams", nt.NewList(vrw, scheduledItems...))
+	}
+
+	if _, ok := x.managedVars["SchemaVersion"]; ok {
+		metastateStruct = metastateStruct.Set(
+			"SchemaVersion",
+			util.Int(x.managedVarSchemaVersion).NomsValue(),
+		)
+	}
+
+	return metastateStruct, nil
 }
 
 var _ marshal.Marshaler = (*Metastate)(nil)
@@ -194,6 +291,183 @@ func (x *Metastate) UnmarshalNoms(value nt.Value) (err error) {
 			// code will work fine, but we can't rely on that right now.
 			err = x.ChildState.UnmarshalNoms(value)
 			err = errors.Wrap(err, "Metastate.UnmarshalNoms->ChildState")
+		// x.managedVarVoteHistorySize (uint64->*ast.Ident) is primitive: true
+		case "VoteHistorySize":
+			// template u_primitive: x.managedVarVoteHistorySize
+			var voteHistorySizeValue util.Int
+			voteHistorySizeValue, err = util.IntFrom(value)
+			if err != nil {
+				err = errors.Wrap(err, "Metastate.UnmarshalNoms->VoteHistorySize")
+				return
+			}
+			x.SetVoteHistorySize(uint64(voteHistorySizeValue))
+		// x.managedVarVoteTotals (map[string]VoteTotals->*ast.MapType) is primitive: false
+		case "VoteTotals":
+			// template u_map: x.managedVarVoteTotals
+			voteTotalsGMap := make(map[string]VoteTotals)
+			if voteTotalsNMap, ok := value.(nt.Map); ok {
+				voteTotalsNMap.Iter(func(voteTotalsKey, voteTotalsValue nt.Value) (stop bool) {
+					voteTotalsKeyString, ok := voteTotalsKey.(nt.String)
+					if !ok {
+						err = fmt.Errorf(
+							"Metastate.UnmarshalNoms expected voteTotalsKey to be a nt.String; found %s",
+							reflect.TypeOf(voteTotalsKey),
+						)
+						return true
+					}
+
+					// template u_nomsmarshaler: voteTotalsValue
+					var voteTotalsValueInstance VoteTotals
+					err = voteTotalsValueInstance.UnmarshalNoms(voteTotalsValue)
+					err = errors.Wrap(err, "Metastate.UnmarshalNoms->voteTotalsValue")
+					if err != nil {
+						return true
+					}
+					voteTotalsGMap[string(voteTotalsKeyString)] = voteTotalsValueInstance
+					return false
+				})
+			} else {
+				err = fmt.Errorf(
+					"Metastate.UnmarshalNoms expected voteTotalsGMap to be a nt.Map; found %s",
+					reflect.TypeOf(value),
+				)
+			}
+
+			x.SetVoteTotals(voteTotalsGMap)
+		// x.managedVarEvidence ([]Evidence->*ast.ArrayType) is primitive: false
+		case "Evidence":
+			// template u_slice: x.managedVarEvidence
+			var evidenceSlice []Evidence
+			if evidenceList, ok := value.(nt.List); ok {
+				evidenceSlice = make([]Evidence, 0, evidenceList.Len())
+				evidenceList.Iter(func(evidenceItem nt.Value, idx uint64) (stop bool) {
+					// template u_nomsmarshaler: evidenceItem
+					var evidenceItemInstance Evidence
+					err = evidenceItemInstance.UnmarshalNoms(evidenceItem)
+					err = errors.Wrap(err, "Metastate.UnmarshalNoms->evidenceItem")
+					if err != nil {
+						return true
+					}
+					evidenceSlice = append(evidenceSlice, evidenceItemInstance)
+					return false
+				})
+			} else {
+				err = fmt.Errorf(
+					"Metastate.UnmarshalNoms expected value to be a nt.List; found %s",
+					reflect.TypeOf(value),
+				)
+			}
+
+			x.SetEvidence(evidenceSlice)
+		// x.managedVarValidatorAddresses (map[string]string->*ast.MapType) is primitive: false
+		case "ValidatorAddresses":
+			// template u_map: x.managedVarValidatorAddresses
+			validatorAddressesGMap := make(map[string]string)
+			if validatorAddressesNMap, ok := value.(nt.Map); ok {
+				validatorAddressesNMap.Iter(func(validatorAddressesKey, validatorAddressesValue nt.Value) (stop bool) {
+					validatorAddressesKeyString, ok := validatorAddressesKey.(nt.String)
+					if !ok {
+						err = fmt.Errorf(
+							"Metastate.UnmarshalNoms expected validatorAddressesKey to be a nt.String; found %s",
+							reflect.TypeOf(validatorAddressesKey),
+						)
+						return true
+					}
+					validatorAddressesValueString, ok := validatorAddressesValue.(nt.String)
+					if !ok {
+						err = fmt.Errorf(
+							"Metastate.UnmarshalNoms expected validatorAddressesValue to be a nt.String; found %s",
+							reflect.TypeOf(validatorAddressesValue),
+						)
+						return true
+					}
+					validatorAddressesGMap[string(validatorAddressesKeyString)] = string(validatorAddressesValueString)
+					return false
+				})
+			} else {
+				err = fmt.Errorf(
+					"Metastate.UnmarshalNoms expected validatorAddressesGMap to be a nt.Map; found %s",
+					reflect.TypeOf(value),
+				)
+			}
+
+			x.SetValidatorAddresses(validatorAddressesGMap)
+		// x.managedVarValidatorPubKeys (map[string]string->*ast.MapType) is primitive: false
+		case "ValidatorPubKeys":
+			// template u_map: x.managedVarValidatorPubKeys
+			validatorPubKeysGMap := make(map[string]string)
+			if validatorPubKeysNMap, ok := value.(nt.Map); ok {
+				validatorPubKeysNMap.Iter(func(validatorPubKeysKey, validatorPubKeysValue nt.Value) (stop bool) {
+					validatorPubKeysKeyString, ok := validatorPubKeysKey.(nt.String)
+					if !ok {
+						err = fmt.Errorf(
+							"Metastate.UnmarshalNoms expected validatorPubKeysKey to be a nt.String; found %s",
+							reflect.TypeOf(validatorPubKeysKey),
+						)
+						return true
+					}
+					validatorPubKeysValueString, ok := validatorPubKeysValue.(nt.String)
+					if !ok {
+						err = fmt.Errorf(
+							"Metastate.UnmarshalNoms expected validatorPubKeysValue to be a nt.String; found %s",
+							reflect.TypeOf(validatorPubKeysValue),
+						)
+						return true
+					}
+					validatorPubKeysGMap[string(validatorPubKeysKeyString)] = string(validatorPubKeysValueString)
+					return false
+				})
+			} else {
+				err = fmt.Errorf(
+					"Metastate.UnmarshalNoms expected validatorPubKeysGMap to be a nt.Map; found %s",
+					reflect.TypeOf(value),
+				)
+			}
+
+			x.SetValidatorPubKeys(validatorPubKeysGMap)
+		// x.managedVarConsensusParams (ConsensusParams->*ast.Ident) is primitive: false
+		case "ConsensusParams":
+			// template u_nomsmarshaler: x.managedVarConsensusParams
+			var consensusParamsInstance ConsensusParams
+			err = consensusParamsInstance.UnmarshalNoms(value)
+			err = errors.Wrap(err, "Metastate.UnmarshalNoms->ConsensusParams")
+
+			x.SetConsensusParams(consensusParamsInstance)
+		// x.managedVarScheduledConsensusParams ([]ScheduledConsensusParams->*ast.ArrayType) is primitive: false
+		case "ScheduledConsensusParams":
+			// template u_slice: x.managedVarScheduledConsensusParams
+			var scheduledSlice []ScheduledConsensusParams
+			if scheduledList, ok := value.(nt.List); ok {
+				scheduledSlice = make([]ScheduledConsensusParams, 0, scheduledList.Len())
+				scheduledList.Iter(func(scheduledItem nt.Value, idx uint64) (stop bool) {
+					// template u_nomsmarshaler: scheduledItem
+					var scheduledItemInstance ScheduledConsensusParams
+					err = scheduledItemInstance.UnmarshalNoms(scheduledItem)
+					err = errors.Wrap(err, "Metastate.UnmarshalNoms->scheduledItem")
+					if err != nil {
+						return true
+					}
+					scheduledSlice = append(scheduledSlice, scheduledItemInstance)
+					return false
+				})
+			} else {
+				err = fmt.Errorf(
+					"Metastate.UnmarshalNoms expected value to be a nt.List; found %s",
+					reflect.TypeOf(value),
+				)
+			}
+
+			x.SetScheduledConsensusParams(scheduledSlice)
+		// x.managedVarSchemaVersion (uint64->*ast.Ident) is primitive: true
+		case "SchemaVersion":
+			// template u_primitive: x.managedVarSchemaVersion
+			var schemaVersionValue util.Int
+			schemaVersionValue, err = util.IntFrom(value)
+			if err != nil {
+				err = errors.Wrap(err, "Metastate.UnmarshalNoms->SchemaVersion")
+				return
+			}
+			x.SetSchemaVersion(uint64(schemaVersionValue))
 		}
 		stop = err != nil
 		return
@@ -202,3 +476,115 @@ func (x *Metastate) UnmarshalNoms(value nt.Value) (err error) {
 }
 
 var _ marshal.Unmarshaler = (*Metastate)(nil)
+
+// GetVoteHistorySize gets the managed variable VoteHistorySize
+func (x Metastate) GetVoteHistorySize() uint64 {
+	return x.managedVarVoteHistorySize
+}
+
+// SetVoteHistorySize sets the managed variable VoteHistorySize
+func (x *Metastate) SetVoteHistorySize(voteHistorySize uint64) {
+	if x.managedVars == nil {
+		x.managedVars = make(map[string]struct{})
+	}
+	x.managedVars["VoteHistorySize"] = struct{}{}
+	x.managedVarVoteHistorySize = voteHistorySize
+}
+
+// GetVoteTotals gets the managed variable VoteTotals
+func (x Metastate) GetVoteTotals() map[string]VoteTotals {
+	return x.managedVarVoteTotals
+}
+
+// SetVoteTotals sets the managed variable VoteTotals
+func (x *Metastate) SetVoteTotals(voteTotals map[string]VoteTotals) {
+	if x.managedVars == nil {
+		x.managedVars = make(map[string]struct{})
+	}
+	x.managedVars["VoteTotals"] = struct{}{}
+	x.managedVarVoteTotals = voteTotals
+}
+
+// GetEvidence gets the managed variable Evidence
+func (x Metastate) GetEvidence() []Evidence {
+	return x.managedVarEvidence
+}
+
+// SetEvidence sets the managed variable Evidence
+func (x *Metastate) SetEvidence(evidence []Evidence) {
+	if x.managedVars == nil {
+		x.managedVars = make(map[string]struct{})
+	}
+	x.managedVars["Evidence"] = struct{}{}
+	x.managedVarEvidence = evidence
+}
+
+// GetValidatorAddresses gets the managed variable ValidatorAddresses
+func (x Metastate) GetValidatorAddresses() map[string]string {
+	return x.managedVarValidatorAddresses
+}
+
+// SetValidatorAddresses sets the managed variable ValidatorAddresses
+func (x *Metastate) SetValidatorAddresses(validatorAddresses map[string]string) {
+	if x.managedVars == nil {
+		x.managedVars = make(map[string]struct{})
+	}
+	x.managedVars["ValidatorAddresses"] = struct{}{}
+	x.managedVarValidatorAddresses = validatorAddresses
+}
+
+// GetValidatorPubKeys gets the managed variable ValidatorPubKeys
+func (x Metastate) GetValidatorPubKeys() map[string]string {
+	return x.managedVarValidatorPubKeys
+}
+
+// SetValidatorPubKeys sets the managed variable ValidatorPubKeys
+func (x *Metastate) SetValidatorPubKeys(validatorPubKeys map[string]string) {
+	if x.managedVars == nil {
+		x.managedVars = make(map[string]struct{})
+	}
+	x.managedVars["ValidatorPubKeys"] = struct{}{}
+	x.managedVarValidatorPubKeys = validatorPubKeys
+}
+
+// GetConsensusParams gets the managed variable ConsensusParams
+func (x Metastate) GetConsensusParams() ConsensusParams {
+	return x.managedVarConsensusParams
+}
+
+// SetConsensusParams sets the managed variable ConsensusParams
+func (x *Metastate) SetConsensusParams(consensusParams ConsensusParams) {
+	if x.managedVars == nil {
+		x.managedVars = make(map[string]struct{})
+	}
+	x.managedVars["ConsensusParams"] = struct{}{}
+	x.managedVarConsensusParams = consensusParams
+}
+
+// GetScheduledConsensusParams gets the managed variable ScheduledConsensusParams
+func (x Metastate) GetScheduledConsensusParams() []ScheduledConsensusParams {
+	return x.managedVarScheduledConsensusParams
+}
+
+// SetScheduledConsensusParams sets the managed variable ScheduledConsensusParams
+func (x *Metastate) SetScheduledConsensusParams(scheduledConsensusParams []ScheduledConsensusParams) {
+	if x.managedVars == nil {
+		x.managedVars = make(map[string]struct{})
+	}
+	x.managedVars["ScheduledConsensusParams"] = struct{}{}
+	x.managedVarScheduledConsensusParams = scheduledConsensusParams
+}
+
+// GetSchemaVersion gets the managed variable SchemaVersion
+func (x Metastate) GetSchemaVersion() uint64 {
+	return x.managedVarSchemaVersion
+}
+
+// SetSchemaVersion sets the managed variable SchemaVersion
+func (x *Metastate) SetSchemaVersion(schemaVersion uint64) {
+	if x.managedVars == nil {
+		x.managedVars = make(map[string]struct{})
+	}
+	x.managedVars["SchemaVersion"] = struct{}{}
+	x.managedVarSchemaVersion = schemaVersion
+}
@@ -0,0 +1,42 @@
+package state
+
+// ----- ---- --- -- -
+// Copyright 2019, 2020 The Axiom Foundation. All Rights Reserved.
+//
+// Licensed under the Apache License 2.0 (the "License").  You may not use
+// this file except in compliance with the License.  You can obtain a copy
+// in the file LICENSE in the source distribution or at
+// https://www.apache.org/licenses/LICENSE-2.0.txt
+// - -- --- ---- -----
+
+import (
+	nt "github.com/ndau/noms/go/types"
+	"github.com/pkg/errors"
+)
+
+// RawState is a State which keeps the child state's noms value as-is
+//
+// It lets tools which don't know the concrete type of an app's child state
+// load metastates, for example with IterMetastates or MetastateAtHeight.
+type RawState struct {
+	Value nt.Value
+}
+
+var _ State = (*RawState)(nil)
+
+// MarshalNoms implements marshal.Marshaler
+func (r RawState) MarshalNoms(vrw nt.ValueReadWriter) (nt.Value, error) {
+	if r.Value == nil {
+		return nil, errors.New("RawState.MarshalNoms: no value")
+	}
+	return r.Value, nil
+}
+
+// UnmarshalNoms implements marshal.Unmarshaler
+func (r *RawState) UnmarshalNoms(v nt.Value) error {
+	r.Value = v
+	return nil
+}
+
+// Init implements State
+func (r *RawState) Init(vrw nt.ValueReadWriter) {}
@@ -0,0 +1,199 @@
+// ----- ---- --- -- -
+// Copyright 2019, 2020 The Axiom Foundation. All Rights Reserved.
+//
+// Licensed under the Apache License 2.0 (the "License").  You may not use
+// this file except in compliance with the License.  You can obtain a copy
+// in the file LICENSE in the source distribution or at
+// https://www.apache.org/licenses/LICENSE-2.0.txt
+// - -- --- ---- -----
+
+// Package statetest checks that State implementations obey the contract on
+// which the metanode relies.
+//
+// Child states are persisted via noms, and the app hash is the hash of the
+// persisted metastate. A State whose marshaling loses data, depends on map
+// iteration order, or whose unmarshaling merges into stale fields instead of
+// overwriting them will eventually corrupt a node or fork the chain.
+package statetest
+
+import (
+	"fmt"
+	"math/rand"
+	"reflect"
+	"testing"
+
+	metast "github.com/ndau/metanode/pkg/meta/state"
+	"github.com/ndau/noms/go/datas"
+	"github.com/ndau/noms/go/hash"
+	"github.com/ndau/noms/go/spec"
+	nt "github.com/ndau/noms/go/types"
+	"github.com/stretchr/testify/require"
+)
+
+// A Generator returns a State whose contents are chosen using r
+//
+// It must be deterministic: the same sequence of random numbers must produce
+// an equal State. It should produce states of varied sizes, including empty
+// ones.
+type Generator func(r *rand.Rand) metast.State
+
+// Options configure Run
+type Options struct {
+	// Iterations is the number of states generated per property.
+	// 0 means 100.
+	Iterations int
+	// Seed is the seed of the first iteration. Every iteration is seeded
+	// separately, so a failure is reproducible from the seed it reports.
+	// 0 means 1.
+	Seed int64
+}
+
+// Run checks that the states produced by gen conform to the State contract
+//
+// Each property is checked in its own subtest:
+//
+//   - RoundTrip: unmarshaling a marshaled state into a fresh instance and
+//     marshaling that reproduces the original value
+//   - InitIdempotent: initializing a fresh instance twice is the same as
+//     initializing it once
+//   - HashStable: equal states, and the same state marshaled twice, have
+//     equal noms hashes
+//   - UnmarshalOverwrites: unmarshaling into a populated instance replaces
+//     its contents rather than merging into them
+//   - Metastate: all of the above, for the state as the child of a Metastate
+func Run(t *testing.T, gen Generator, opts Options) {
+	if opts.Iterations == 0 {
+		opts.Iterations = 100
+	}
+	if opts.Seed == 0 {
+		opts.Seed = 1
+	}
+
+	sp, err := spec.ForDatabase("mem")
+	require.NoError(t, err)
+	db := sp.GetDatabase()
+	defer db.Close()
+
+	s := suite{gen: gen, opts: opts, db: db}
+	t.Run("RoundTrip", s.roundTrip)
+	t.Run("InitIdempotent", s.initIdempotent)
+	t.Run("HashStable", s.hashStable)
+	t.Run("UnmarshalOverwrites", s.unmarshalOverwrites)
+	t.Run("Metastate", s.metastate)
+}
+
+type suite struct {
+	gen  Generator
+	opts Options
+	db   datas.Database
+}
+
+// each runs f as a subtest once per iteration, named for the iteration's
+// seed, and stops at the first failure
+func (s suite) each(t *testing.T, f func(t *testing.T, seed int64)) {
+	for i := 0; i < s.opts.Iterations; i++ {
+		seed := s.opts.Seed + int64(i)
+		ok := t.Run(fmt.Sprintf("seed=%d", seed), func(t *testing.T) {
+			f(t, seed)
+		})
+		if !ok {
+			return
+		}
+	}
+}
+
+func (s suite) generate(t *testing.T, seed int64) metast.State {
+	state := s.gen(rand.New(rand.NewSource(seed)))
+	require.NotNil(t, state, "generator returned nil")
+	return state
+}
+
+// fresh returns a new, initialized instance of the state's type
+func (s suite) fresh(state metast.State) metast.State {
+	stateType := reflect.Indirect(reflect.ValueOf(state)).Type()
+	fresh := reflect.New(stateType).Interface().(metast.State)
+	fresh.Init(s.db)
+	return fresh
+}
+
+func (s suite) marshal(t *testing.T, state metast.State) nt.Value {
+	value, err := state.MarshalNoms(s.db)
+	require.NoError(t, err)
+	return value
+}
+
+func (s suite) hash(t *testing.T, state metast.State) hash.Hash {
+	return s.marshal(t, state).Hash()
+}
+
+func (s suite) roundTrip(t *testing.T) {
+	s.each(t, func(t *testing.T, seed int64) {
+		state := s.generate(t, seed)
+		value := s.marshal(t, state)
+
+		decoded := s.fresh(state)
+		require.NoError(t, decoded.UnmarshalNoms(value))
+		require.Equal(t, value.Hash(), s.hash(t, decoded), "round trip changed the state")
+	})
+}
+
+func (s suite) initIdempotent(t *testing.T) {
+	state := s.generate(t, s.opts.Seed)
+	once := s.fresh(state)
+	twice := s.fresh(state)
+	twice.Init(s.db)
+	require.Equal(t, s.hash(t, once), s.hash(t, twice), "Init is not idempotent")
+}
+
+func (s suite) hashStable(t *testing.T) {
+	s.each(t, func(t *testing.T, seed int64) {
+		state := s.generate(t, seed)
+		h := s.hash(t, state)
+		require.Equal(t, h, s.hash(t, state), "marshaling the same state twice produced different hashes")
+		require.Equal(t, h, s.hash(t, s.generate(t, seed)), "equal states produced different hashes")
+	})
+}
+
+func (s suite) unmarshalOverwrites(t *testing.T) {
+	s.each(t, func(t *testing.T, seed int64) {
+		stale := s.generate(t, seed)
+		target := s.fresh(stale)
+		require.NoError(t, target.UnmarshalNoms(s.marshal(t, stale)))
+
+		for _, want := range []metast.State{
+			s.generate(t, seed+int64(s.opts.Iterations)),
+			s.fresh(stale),
+		} {
+			value := s.marshal(t, want)
+			require.NoError(t, target.UnmarshalNoms(value))
+			require.Equal(t, value.Hash(), s.hash(t, target), "unmarshaling merged into stale contents")
+		}
+	})
+}
+
+func (s suite) marshalMeta(t *testing.T, child metast.State) nt.Value {
+	value, err := metast.Metastate{ChildState: child}.MarshalNoms(s.db)
+	require.NoError(t, err)
+	return value
+}
+
+func (s suite) metastate(t *testing.T) {
+	s.each(t, func(t *testing.T, seed int64) {
+		state := s.generate(t, seed)
+		value := s.marshalMeta(t, state)
+
+		// round trip
+		decoded := metast.Metastate{ChildState: s.fresh(state)}
+		require.NoError(t, decoded.UnmarshalNoms(value))
+		require.Equal(t, value.Hash(), s.marshalMeta(t, decoded.ChildState).Hash(), "metastate round trip changed the child state")
+
+		// hash stability
+		require.Equal(t, value.Hash(), s.marshalMeta(t, s.generate(t, seed)).Hash(), "equal child states produced different metastate hashes")
+
+		// overwriting
+		want := s.generate(t, seed+int64(s.opts.Iterations))
+		wantValue := s.marshalMeta(t, want)
+		require.NoError(t, decoded.UnmarshalNoms(wantValue))
+		require.Equal(t, wantValue.Hash(), s.marshalMeta(t, decoded.ChildState).Hash(), "metastate unmarshaling merged into a stale child state")
+	})
+}
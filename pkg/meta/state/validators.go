@@ -11,7 +11,9 @@ package state
 
 
 import (
+	"bytes"
 	"encoding/base64"
+	"sort"
 
 	"github.com/ndau/noms/go/datas"
 	"github.com/pkg/errors"
@@ -20,53 +22,210 @@ import (
 	tmconv "github.com/tendermint/tendermint/types"
 )
 
+// ValidatorInfo describes a single member of the validator set
+type ValidatorInfo struct {
+	PubKey  abci.PubKey
+	Address []byte
+	Power   int64
+}
+
+// AsValidator converts this ValidatorInfo into a tendermint Validator
+func (vi ValidatorInfo) AsValidator() abci.Validator {
+	return abci.Validator{
+		Address: vi.Address,
+		Power:   vi.Power,
+	}
+}
+
+func encodePubKey(pk abci.PubKey) (string, error) {
+	pkB, err := pk.Marshal()
+	if err != nil {
+		return "", errors.Wrap(err, "marshal public key")
+	}
+	return base64.StdEncoding.EncodeToString(pkB), nil
+}
+
+func decodePubKey(pubkeyB64 string) (abci.PubKey, error) {
+	pk := abci.PubKey{}
+	pkB, err := base64.StdEncoding.DecodeString(pubkeyB64)
+	if err != nil {
+		return pk, errors.Wrap(err, "decode pubkey b64")
+	}
+	err = pk.Unmarshal(pkB)
+	return pk, errors.Wrap(err, "unmarshal public key")
+}
+
+// addressOf transforms a pubkey into an address
+func addressOf(pk abci.PubKey) ([]byte, error) {
+	// note that this conversion function is specifically marked as UNSTABLE
+	// in the TM source, so we should expect this to break.
+	// OTOH, there's apparently no stable way to make this conversion happen,
+	// so here we are. This is why we cache the results in the address index.
+	// https://github.com/tendermint/tendermint/blob/0c9c3292c918617624f6f3fbcd95eceade18bcd5/types/protobuf.go#L170-L171
+	var tcpk crypto.PubKey
+	tcpk, err := tmconv.PB2TM.PubKey(pk)
+	if err != nil {
+		return nil, errors.Wrap(err, "convert tm.abci pk into tm.crypto pk")
+	}
+	return tcpk.Address(), nil
+}
+
 // UpdateValidator updates the app's internal state with the given validator
+//
+// If the validator address index is maintained, the validator's address is
+// added to it. Addresses are never removed from the index, so that evidence
+// against former validators can still be attributed.
 func (state *Metastate) UpdateValidator(db datas.Database, v abci.ValidatorUpdate) error {
-	pk := v.GetPubKey()
-	pkB, err := pk.Marshal()
+	pkS, err := encodePubKey(v.GetPubKey())
 	if err != nil {
-		return errors.Wrap(err, "Metastate.UpdateValidator->v.PubKey.Marshal")
+		return errors.Wrap(err, "Metastate.UpdateValidator")
 	}
-	pkS := base64.StdEncoding.EncodeToString(pkB)
 	if v.Power == 0 {
 		delete(state.Validators, pkS)
 	} else {
 		state.Validators[pkS] = v.GetPower()
 	}
+	if state.IsIndexingValidatorAddresses() {
+		if _, ok := state.managedVarValidatorAddresses[pkS]; !ok {
+			address, err := addressOf(v.GetPubKey())
+			if err != nil {
+				return errors.Wrap(err, "Metastate.UpdateValidator")
+			}
+			addressB64 := base64.StdEncoding.EncodeToString(address)
+			state.managedVarValidatorAddresses[pkS] = addressB64
+			state.managedVarValidatorPubKeys[addressB64] = pkS
+		}
+	}
 	return nil
 }
 
-// GetValidators returns a list of validators this app knows of
-func (state *Metastate) GetValidators() (validators []abci.Validator, err error) {
-	for pubkeyB64, power := range state.Validators {
-		pkB, err := base64.StdEncoding.DecodeString(pubkeyB64)
+// IndexValidatorAddresses begins maintaining the index between validator
+// pubkeys and addresses
+//
+// The index is kept in both directions, so that lookups are constant-time
+// either way. It is populated with the current validator set. As with the other
+// optional metastate fields, this is opt-in so that existing chains can replay
+// their history. It is a no-op if the index is already maintained.
+func (state *Metastate) IndexValidatorAddresses() error {
+	if state.IsIndexingValidatorAddresses() {
+		return nil
+	}
+	index := make(map[string]string, len(state.Validators))
+	reverse := make(map[string]string, len(state.Validators))
+	for pubkeyB64 := range state.Validators {
+		pk, err := decodePubKey(pubkeyB64)
 		if err != nil {
-			return nil, errors.Wrap(err, "Metastate.GetValidators->decode pubkey b64")
+			return errors.Wrap(err, "Metastate.IndexValidatorAddresses")
 		}
-		pk := abci.PubKey{}
-		err = pk.Unmarshal(pkB)
+		address, err := addressOf(pk)
 		if err != nil {
-			return nil, errors.Wrap(err, "GetValidators: unmarshal public key")
+			return errors.Wrap(err, "Metastate.IndexValidatorAddresses")
 		}
+		addressB64 := base64.StdEncoding.EncodeToString(address)
+		index[pubkeyB64] = addressB64
+		reverse[addressB64] = pubkeyB64
+	}
+	state.SetValidatorAddresses(index)
+	state.SetValidatorPubKeys(reverse)
+	return nil
+}
+
+// IsIndexingValidatorAddresses is true when the pubkey and address index is maintained
+func (state *Metastate) IsIndexingValidatorAddresses() bool {
+	_, ok := state.managedVars["ValidatorAddresses"]
+	return ok
+}
+
+// AddressOf returns the address of the validator with the given public key
+//
+// The index is consulted first; otherwise, the address is computed.
+func (state *Metastate) AddressOf(pk abci.PubKey) ([]byte, error) {
+	pkS, err := encodePubKey(pk)
+	if err != nil {
+		return nil, errors.Wrap(err, "Metastate.AddressOf")
+	}
+	if addressB64, ok := state.managedVarValidatorAddresses[pkS]; ok {
+		address, err := base64.StdEncoding.DecodeString(addressB64)
+		return address, errors.Wrap(err, "Metastate.AddressOf->decode address b64")
+	}
+	address, err := addressOf(pk)
+	return address, errors.Wrap(err, "Metastate.AddressOf")
+}
 
-		// transform pubkey to address
-		// note that this conversion function is specifically marked as UNSTABLE
-		// in the TM source, so we should expect this to break.
-		// OTOH, there's apparently no stable way to make this conversion happen,
-		// so here we are.
-		// https://github.com/tendermint/tendermint/blob/0c9c3292c918617624f6f3fbcd95eceade18bcd5/types/protobuf.go#L170-L171
-		var tcpk crypto.PubKey
-		tcpk, err = tmconv.PB2TM.PubKey(pk)
+// PubKeyOf returns the public key of the validator with the given address
+//
+// If the address index is maintained, any validator which has ever been in
+// the validator set since indexing began can be found. Otherwise, only the
+// current validator set is searched.
+func (state *Metastate) PubKeyOf(address []byte) (pk abci.PubKey, found bool, err error) {
+	addressB64 := base64.StdEncoding.EncodeToString(address)
+	if state.IsIndexingValidatorAddresses() {
+		pubkeyB64, ok := state.managedVarValidatorPubKeys[addressB64]
+		if !ok {
+			return
+		}
+		pk, err = decodePubKey(pubkeyB64)
+		return pk, err == nil, errors.Wrap(err, "Metastate.PubKeyOf")
+	}
+	for pubkeyB64 := range state.Validators {
+		pk, err = decodePubKey(pubkeyB64)
+		if err != nil {
+			return pk, false, errors.Wrap(err, "Metastate.PubKeyOf")
+		}
+		var vAddress []byte
+		vAddress, err = addressOf(pk)
 		if err != nil {
-			return nil, errors.Wrap(err, "GetValidators: convert tm.abci pk into tm.crypto pk")
+			return pk, false, errors.Wrap(err, "Metastate.PubKeyOf")
 		}
-		address := tcpk.Address()
+		if bytes.Equal(vAddress, address) {
+			return pk, true, nil
+		}
+	}
+	return abci.PubKey{}, false, nil
+}
 
-		// finish
-		validators = append(validators, abci.Validator{
+// GetValidators returns a list of validators this app knows of
+//
+// Validators are sorted as by GetValidatorInfos.
+func (state *Metastate) GetValidators() (validators []abci.Validator, err error) {
+	infos, err := state.GetValidatorInfos()
+	if err != nil {
+		return nil, err
+	}
+	validators = make([]abci.Validator, 0, len(infos))
+	for _, vi := range infos {
+		validators = append(validators, vi.AsValidator())
+	}
+	return
+}
+
+// GetValidatorInfos returns a list of validators this app knows of, with
+// their public keys
+//
+// Validators are sorted as tendermint sorts them: by descending power,
+// then by ascending address.
+func (state *Metastate) GetValidatorInfos() (validators []ValidatorInfo, err error) {
+	validators = make([]ValidatorInfo, 0, len(state.Validators))
+	for pubkeyB64, power := range state.Validators {
+		pk, err := decodePubKey(pubkeyB64)
+		if err != nil {
+			return nil, errors.Wrap(err, "Metastate.GetValidatorInfos")
+		}
+		address, err := state.AddressOf(pk)
+		if err != nil {
+			return nil, errors.Wrap(err, "Metastate.GetValidatorInfos")
+		}
+		validators = append(validators, ValidatorInfo{
+			PubKey:  pk,
 			Address: address,
 			Power:   power,
 		})
 	}
+	sort.Slice(validators, func(i, j int) bool {
+		if validators[i].Power != validators[j].Power {
+			return validators[i].Power > validators[j].Power
+		}
+		return bytes.Compare(validators[i].Address, validators[j].Address) < 0
+	})
 	return
 }
@@ -17,14 +17,14 @@ import (
 	abci "github.com/tendermint/tendermint/abci/types"
 )
 
-// HistorySize is how much history we keep for node performance analysis
+// HistorySize is the default amount of history we keep for node performance analysis
 //
-// We may want this to be a variable stored in metastate in the future,
-// but for now, a const is good enough.
+// Chains can override this by setting the VoteHistorySize managed variable
+// in the metastate; see Metastate.SetVoteHistorySize.
 const HistorySize = 20
 
 // generate noms marshalers
-//nomsify NodeRoundStats RoundStats VoteStats
+//nomsify NodeRoundStats RoundStats VoteStats VoteTotals
 
 // NodeRoundStats contains information about the votes of a particular node in a particular round
 type NodeRoundStats struct {
@@ -86,7 +86,8 @@ func MakeRoundStats(logger log.FieldLogger, req abci.RequestBeginBlock) RoundSta
 		})
 		if uint64(ev.Height) != rs.Height {
 			logger.WithField("lastCommit.height", rs.Height).Warn("presented with historical evidence of byzantine validation")
-			// but we can't do anything about it now
+			// it can't affect this round's stats, but Metastate.RecordEvidence
+			// keeps it in the evidence registry
 			continue
 		}
 
@@ -116,16 +117,77 @@ type VoteStats struct {
 
 // Append the provided RoundStats to the history
 //
-// Retain no more than HistorySize items.
-func (vs *VoteStats) Append(rs RoundStats) {
-	idx0 := len(vs.History) - HistorySize + 1
+// Retain no more than size items. If size is 0, HistorySize is used.
+func (vs *VoteStats) Append(rs RoundStats, size uint64) {
+	if size == 0 {
+		size = HistorySize
+	}
+	idx0 := len(vs.History) - int(size) + 1
 	if idx0 < 0 {
 		idx0 = 0
 	}
 	vs.History = append(vs.History[idx0:], rs)
 }
 
+// VoteTotals are the aggregated voting statistics of a single validator
+// since vote totals tracking began.
+//
+// Unlike VoteStats, these are never windowed: they grow monotonically.
+type VoteTotals struct {
+	Rounds           uint64
+	Voted            uint64
+	AgainstConsensus uint64
+	FirstHeight      uint64
+	LastHeight       uint64
+}
+
+// Add the given node round stats, which occurred at the given height, to the totals
+func (vt *VoteTotals) Add(height uint64, nrs NodeRoundStats) {
+	if vt.Rounds == 0 {
+		vt.FirstHeight = height
+	}
+	vt.Rounds++
+	if nrs.Voted {
+		vt.Voted++
+	}
+	if nrs.AgainstConsensus {
+		vt.AgainstConsensus++
+	}
+	vt.LastHeight = height
+}
+
+// TrackVoteTotals begins accumulating per-validator vote totals
+//
+// Totals are not tracked by default: doing so for an existing chain would
+// change the app hash of every replayed block. New chains should call this
+// during InitChain; existing chains should call it in response to a
+// governance transaction. It is a no-op if totals are already tracked.
+func (m *Metastate) TrackVoteTotals() {
+	if !m.IsTrackingVoteTotals() {
+		m.SetVoteTotals(make(map[string]VoteTotals))
+	}
+}
+
+// IsTrackingVoteTotals is true when per-validator vote totals are accumulated
+func (m *Metastate) IsTrackingVoteTotals() bool {
+	_, ok := m.managedVars["VoteTotals"]
+	return ok
+}
+
 // AppendRoundStats appends round statistics of the current round to the metastate
+//
+// If vote totals are tracked, they are updated as well.
 func (m *Metastate) AppendRoundStats(logger log.FieldLogger, req abci.RequestBeginBlock) {
-	m.Stats.Append(MakeRoundStats(logger, req))
+	rs := MakeRoundStats(logger, req)
+	m.Stats.Append(rs, m.GetVoteHistorySize())
+
+	if m.IsTrackingVoteTotals() {
+		totals := m.GetVoteTotals()
+		for addr, nrs := range rs.Validators {
+			vt := totals[addr]
+			vt.Add(rs.Height, nrs)
+			totals[addr] = vt
+		}
+		m.SetVoteTotals(totals)
+	}
 }
@@ -350,3 +350,138 @@ func (x *VoteStats) UnmarshalNoms(value nt.Value) (err error) {
 }
 
 var _ marshal.Unmarshaler = (*VoteStats)(nil)
+
+var voteTotalsStructTemplate nt.StructTemplate
+
+func init() {
+	voteTotalsStructTemplate = nt.MakeStructTemplate("VoteTotals", []string{
+		"AgainstConsensus",
+		"FirstHeight",
+		"LastHeight",
+		"Rounds",
+		"Voted",
+	})
+}
+
+// MarshalNoms implements noms/go/marshal.Marshaler
+func (x VoteTotals) MarshalNoms(vrw nt.ValueReadWriter) (voteTotalsValue nt.Value, err error) {
+	// x.Rounds (uint64->*ast.Ident) is primitive: true
+
+	// x.Voted (uint64->*ast.Ident) is primitive: true
+
+	// x.AgainstConsensus (uint64->*ast.Ident) is primitive: true
+
+	// x.FirstHeight (uint64->*ast.Ident) is primitive: true
+
+	// x.LastHeight (uint64->*ast.Ident) is primitive: true
+
+	values := []nt.Value{
+		// x.AgainstConsensus (uint64)
+		util.Int(x.AgainstConsensus).NomsValue(),
+		// x.FirstHeight (uint64)
+		util.Int(x.FirstHeight).NomsValue(),
+		// x.LastHeight (uint64)
+		util.Int(x.LastHeight).NomsValue(),
+		// x.Rounds (uint64)
+		util.Int(x.Rounds).NomsValue(),
+		// x.Voted (uint64)
+		util.Int(x.Voted).NomsValue(),
+	}
+
+	return voteTotalsStructTemplate.NewStruct(values), nil
+}
+
+var _ marshal.Marshaler = (*VoteTotals)(nil)
+
+// UnmarshalNoms implements noms/go/marshal.Unmarshaler
+//
+// This method makes no attempt to zeroize the provided struct; it simply
+// overwrites fields as they are found.
+func (x *VoteTotals) UnmarshalNoms(value nt.Value) (err error) {
+	vs, ok := value.(nt.Struct)
+	if !ok {
+		return fmt.Errorf(
+			"VoteTotals.UnmarshalNoms expected a nt.Value; found %s",
+			reflect.TypeOf(value),
+		)
+	}
+
+	// noms Struct.MaybeGet isn't efficient: it iterates over all fields of
+	// the struct until it finds one whose name happens to match the one sought.
+	// It's better to iterate once over the struct and set the fields of the
+	// target struct in arbitrary order.
+	vs.IterFields(func(name string, value nt.Value) (stop bool) {
+		switch name {
+		// x.Rounds (uint64->*ast.Ident) is primitive: true
+		case "Rounds":
+			// template u_decompose: x.Rounds (uint64->*ast.Ident)
+			// template u_primitive: x.Rounds
+			var roundsValue util.Int
+			roundsValue, err = util.IntFrom(value)
+			if err != nil {
+				err = errors.Wrap(err, "VoteTotals.UnmarshalNoms->Rounds")
+				return
+			}
+			roundsTyped := uint64(roundsValue)
+
+			x.Rounds = roundsTyped
+		// x.Voted (uint64->*ast.Ident) is primitive: true
+		case "Voted":
+			// template u_decompose: x.Voted (uint64->*ast.Ident)
+			// template u_primitive: x.Voted
+			var votedValue util.Int
+			votedValue, err = util.IntFrom(value)
+			if err != nil {
+				err = errors.Wrap(err, "VoteTotals.UnmarshalNoms->Voted")
+				return
+			}
+			votedTyped := uint64(votedValue)
+
+			x.Voted = votedTyped
+		// x.AgainstConsensus (uint64->*ast.Ident) is primitive: true
+		case "AgainstConsensus":
+			// template u_decompose: x.AgainstConsensus (uint64->*ast.Ident)
+			// template u_primitive: x.AgainstConsensus
+			var againstConsensusValue util.Int
+			againstConsensusValue, err = util.IntFrom(value)
+			if err != nil {
+				err = errors.Wrap(err, "VoteTotals.UnmarshalNoms->AgainstConsensus")
+				return
+			}
+			againstConsensusTyped := uint64(againstConsensusValue)
+
+			x.AgainstConsensus = againstConsensusTyped
+		// x.FirstHeight (uint64->*ast.Ident) is primitive: true
+		case "FirstHeight":
+			// template u_decompose: x.FirstHeight (uint64->*ast.Ident)
+			// template u_primitive: x.FirstHeight
+			var firstHeightValue util.Int
+			firstHeightValue, err = util.IntFrom(value)
+			if err != nil {
+				err = errors.Wrap(err, "VoteTotals.UnmarshalNoms->FirstHeight")
+				return
+			}
+			firstHeightTyped := uint64(firstHeightValue)
+
+			x.FirstHeight = firstHeightTyped
+		// x.LastHeight (uint64->*ast.Ident) is primitive: true
+		case "LastHeight":
+			// template u_decompose: x.LastHeight (uint64->*ast.Ident)
+			// template u_primitive: x.LastHeight
+			var lastHeightValue util.Int
+			lastHeightValue, err = util.IntFrom(value)
+			if err != nil {
+				err = errors.Wrap(err, "VoteTotals.UnmarshalNoms->LastHeight")
+				return
+			}
+			lastHeightTyped := uint64(lastHeightValue)
+
+			x.LastHeight = lastHeightTyped
+		}
+		stop = err != nil
+		return
+	})
+	return
+}
+
+var _ marshal.Unmarshaler = (*VoteTotals)(nil)
@@ -18,13 +18,28 @@ import (
 )
 
 //go:generate msgp
-//msgp:shim TxID as:uint8 using:uint8/TxID
+//msgp:shim TxID as:uint32 using:uint32/TxID
 //msgp:ignore TxIDMap
 
 // A TxID is a unique identity for this transaction type.
 //
 // This eases disambiguation for deserialization.
-type TxID uint8
+//
+// TxIDs were originally uint8. msgpack encodes every integer in its shortest
+// form, so widening the type doesn't change the wire format: transactions
+// encoded with the uint8 shim decode unchanged, and IDs up to MaxLegacyTxID
+// encode to the same bytes as before, preserving transaction hashes.
+// Nodes which predate the widening can't decode larger IDs.
+type TxID uint32
+
+// MaxLegacyTxID is the largest TxID representable before TxIDs were widened
+const MaxLegacyTxID TxID = 255
+
+// IsLegacy is true when this TxID can be decoded by nodes which predate the
+// widening of TxIDs
+func (id TxID) IsLegacy() bool {
+	return id <= MaxLegacyTxID
+}
 
 // A TxIDMap is a map of unique IDs to Transactable example objects
 //
@@ -42,6 +57,9 @@ func NameOf(txab Transactable) string {
 //
 // We're stuck with linear scan here, which isn't ideal, but for the
 // size of transactables we expect, the penalty shouldn't be too bad.
+//
+// Types are compared by unqualified name, so identically-named types from
+// different packages collide. A Registry has neither limitation.
 func TxIDOf(txab Transactable, idMap TxIDMap) (TxID, error) {
 	txabName := NameOf(txab)
 	if len(txabName) == 0 {
@@ -9,8 +9,8 @@ import (
 // DecodeMsg implements msgp.Decodable
 func (z *TxID) DecodeMsg(dc *msgp.Reader) (err error) {
 	{
-		var zb0001 uint8
-		zb0001, err = dc.ReadUint8()
+		var zb0001 uint32
+		zb0001, err = dc.ReadUint32()
 		if err != nil {
 			err = msgp.WrapError(err)
 			return
@@ -22,7 +22,7 @@ func (z *TxID) DecodeMsg(dc *msgp.Reader) (err error) {
 
 // EncodeMsg implements msgp.Encodable
 func (z TxID) EncodeMsg(en *msgp.Writer) (err error) {
-	err = en.WriteUint8(uint8(z))
+	err = en.WriteUint32(uint32(z))
 	if err != nil {
 		err = msgp.WrapError(err)
 		return
@@ -33,15 +33,15 @@ func (z TxID) EncodeMsg(en *msgp.Writer) (err error) {
 // MarshalMsg implements msgp.Marshaler
 func (z TxID) MarshalMsg(b []byte) (o []byte, err error) {
 	o = msgp.Require(b, z.Msgsize())
-	o = msgp.AppendUint8(o, uint8(z))
+	o = msgp.AppendUint32(o, uint32(z))
 	return
 }
 
 // UnmarshalMsg implements msgp.Unmarshaler
 func (z *TxID) UnmarshalMsg(bts []byte) (o []byte, err error) {
 	{
-		var zb0001 uint8
-		zb0001, bts, err = msgp.ReadUint8Bytes(bts)
+		var zb0001 uint32
+		zb0001, bts, err = msgp.ReadUint32Bytes(bts)
 		if err != nil {
 			err = msgp.WrapError(err)
 			return
@@ -54,6 +54,6 @@ func (z *TxID) UnmarshalMsg(bts []byte) (o []byte, err error) {
 
 // Msgsize returns an upper bound estimate of the number of bytes occupied by the serialized message
 func (z TxID) Msgsize() (s int) {
-	s = msgp.Uint8Size
+	s = msgp.Uint32Size
 	return
 }
@@ -0,0 +1,144 @@
+package metatx
+
+// ----- ---- --- -- -
+// Copyright 2019, 2020 The Axiom Foundation. All Rights Reserved.
+//
+// Licensed under the Apache License 2.0 (the "License").  You may not use
+// this file except in compliance with the License.  You can obtain a copy
+// in the file LICENSE in the source distribution or at
+// https://www.apache.org/licenses/LICENSE-2.0.txt
+// - -- --- ---- -----
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/pkg/errors"
+)
+
+// jsonTransaction is the JSON representation of a Transaction
+//
+// The msgp-encoded transactable is replaced by its JSON encoding, and the
+// TxID by the type's name in the registry. Transactables control their
+// encoding by implementing json.Marshaler and json.Unmarshaler.
+type jsonTransaction struct {
+	Nonce        []byte          `json:"nonce"`
+	Type         string          `json:"type"`
+	Transactable json.RawMessage `json:"transactable"`
+}
+
+// NameOf returns the canonical name of a registered type
+//
+// This is its first alias if it has any, its unqualified name if that is
+// unambiguous, and its fully qualified name otherwise. Lookup resolves it.
+func (r *Registry) NameOf(id TxID) (string, error) {
+	reg, ok := r.byID[id]
+	if !ok {
+		return "", fmt.Errorf("TxID %d is not registered", id)
+	}
+	if len(reg.Aliases) > 0 {
+		return reg.Aliases[0], nil
+	}
+	if len(r.byName[reg.Name]) == 1 {
+		return reg.Name, nil
+	}
+	return reg.FullName, nil
+}
+
+// decodeJSON decodes JSON strictly: unknown fields are errors
+func decodeJSON(data []byte, v interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	err := decoder.Decode(v)
+	if err != nil {
+		return err
+	}
+	if decoder.More() {
+		return errors.New("unexpected data after JSON value")
+	}
+	return nil
+}
+
+// ToJSON encodes the Transaction as JSON
+//
+// The encoding is canonical: encoding the same Transaction always produces
+// the same bytes.
+func (tx *Transaction) ToJSON(registry *Registry) ([]byte, error) {
+	name, err := registry.NameOf(tx.TransactableID)
+	if err != nil {
+		return nil, errors.Wrap(err, "Transaction.ToJSON")
+	}
+	txab, err := tx.AsTransactableWithResolver(registry)
+	if err != nil {
+		return nil, errors.Wrap(err, "Transaction.ToJSON")
+	}
+	txabJSON, err := json.Marshal(txab)
+	if err != nil {
+		return nil, errors.Wrap(err, "Transaction.ToJSON: encoding transactable")
+	}
+	return json.Marshal(jsonTransaction{
+		Nonce:        tx.Nonce,
+		Type:         name,
+		Transactable: txabJSON,
+	})
+}
+
+// TransactionFromJSON decodes a Transaction encoded by ToJSON
+//
+// The transactable is re-encoded as msgp, so the resulting Transaction can
+// be submitted to the chain.
+func TransactionFromJSON(data []byte, registry *Registry) (*Transaction, error) {
+	jtx := jsonTransaction{}
+	err := decodeJSON(data, &jtx)
+	if err != nil {
+		return nil, errors.Wrap(err, "TransactionFromJSON")
+	}
+	id, err := registry.Lookup(jtx.Type)
+	if err != nil {
+		return nil, errors.Wrap(err, "TransactionFromJSON")
+	}
+	txab, err := transactableFromJSON(id, jtx.Transactable, registry)
+	if err != nil {
+		return nil, errors.Wrap(err, "TransactionFromJSON")
+	}
+	txabBytes, err := txab.MarshalMsg(nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "TransactionFromJSON: encoding transactable")
+	}
+	return &Transaction{
+		Nonce:          jtx.Nonce,
+		TransactableID: id,
+		Transactable:   txabBytes,
+	}, nil
+}
+
+func transactableFromJSON(id TxID, data []byte, registry *Registry) (Transactable, error) {
+	example, ok := registry.Example(id)
+	if !ok {
+		return nil, fmt.Errorf("Unknown TransactableID: %d", id)
+	}
+	txab := Clone(example)
+	err := decodeJSON(data, txab)
+	return txab, errors.Wrap(err, "decoding transactable")
+}
+
+// MarshalJSON serializes a Transactable into a JSON Transaction
+//
+// Like Marshal, it generates a fresh nonce.
+func MarshalJSON(txab Transactable, registry *Registry) ([]byte, error) {
+	tx, err := AsTransactionWithResolver(txab, registry)
+	if err != nil {
+		return nil, err
+	}
+	return tx.ToJSON(registry)
+}
+
+// UnmarshalJSON constructs a Transactable from a JSON Transaction
+func UnmarshalJSON(data []byte, registry *Registry) (Transactable, error) {
+	tx, err := TransactionFromJSON(data, registry)
+	if err != nil {
+		return nil, err
+	}
+	return tx.AsTransactableWithResolver(registry)
+}
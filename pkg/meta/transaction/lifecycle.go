@@ -0,0 +1,129 @@
+package metatx
+
+// ----- ---- --- -- -
+// Copyright 2019, 2020 The Axiom Foundation. All Rights Reserved.
+//
+// Licensed under the Apache License 2.0 (the "License").  You may not use
+// this file except in compliance with the License.  You can obtain a copy
+// in the file LICENSE in the source distribution or at
+// https://www.apache.org/licenses/LICENSE-2.0.txt
+// - -- --- ---- -----
+
+import (
+	"fmt"
+)
+
+// TxMetadata describes the lifecycle of a Transactable type
+//
+// Heights are tendermint heights. Because the lifecycle is keyed by height,
+// historical blocks replay with the rules in effect when they were produced.
+type TxMetadata struct {
+	// ValidFrom is the first height at which the type is accepted.
+	// 0 means the type has always been accepted.
+	ValidFrom uint64
+	// ValidUntil is the last height at which the type is accepted: its
+	// sunset height. 0 means the type is accepted indefinitely.
+	ValidUntil uint64
+	// Deprecation is a notice explaining why the type is being retired.
+	// A non-empty notice marks the type as deprecated.
+	Deprecation string
+	// Successor is the TxID of the type which replaces this one.
+	// 0 means there is no successor.
+	Successor TxID
+}
+
+// IsDeprecated is true when the type has a deprecation notice or a sunset height
+func (md TxMetadata) IsDeprecated() bool {
+	return md.Deprecation != "" || md.ValidUntil != 0
+}
+
+// ErrNotYetValid is returned when a type is used before its ValidFrom height
+type ErrNotYetValid struct {
+	ID        TxID
+	Height    uint64
+	ValidFrom uint64
+}
+
+func (e ErrNotYetValid) Error() string {
+	return fmt.Sprintf("TxID %d is not valid until height %d (current %d)", e.ID, e.ValidFrom, e.Height)
+}
+
+// ErrSunset is returned when a type is used after its ValidUntil height
+type ErrSunset struct {
+	ID          TxID
+	Height      uint64
+	ValidUntil  uint64
+	Deprecation string
+	Successor   string
+}
+
+func (e ErrSunset) Error() string {
+	msg := fmt.Sprintf("TxID %d was retired after height %d (current %d)", e.ID, e.ValidUntil, e.Height)
+	if e.Deprecation != "" {
+		msg += ": " + e.Deprecation
+	}
+	if e.Successor != "" {
+		msg += "; use " + e.Successor + " instead"
+	}
+	return msg
+}
+
+// SetMetadata sets the lifecycle metadata of a registered type
+//
+// The successor, if any, must also be registered.
+func (r *Registry) SetMetadata(id TxID, md TxMetadata) error {
+	reg, ok := r.byID[id]
+	if !ok {
+		return fmt.Errorf("TxID %d is not registered", id)
+	}
+	if md.ValidUntil != 0 && md.ValidUntil < md.ValidFrom {
+		return fmt.Errorf("TxID %d: ValidUntil %d precedes ValidFrom %d", id, md.ValidUntil, md.ValidFrom)
+	}
+	if md.Successor != 0 {
+		if md.Successor == id {
+			return fmt.Errorf("TxID %d can't succeed itself", id)
+		}
+		if _, ok := r.byID[md.Successor]; !ok {
+			return fmt.Errorf("TxID %d: successor %d is not registered", id, md.Successor)
+		}
+	}
+	reg.Metadata = md
+	return nil
+}
+
+// Metadata returns the lifecycle metadata of a registered type
+func (r *Registry) Metadata(id TxID) (TxMetadata, bool) {
+	reg, ok := r.byID[id]
+	if !ok {
+		return TxMetadata{}, false
+	}
+	return reg.Metadata, true
+}
+
+// CheckActive returns an error if the type with this ID may not be used at
+// the given height
+//
+// The error is an ErrNotYetValid or an ErrSunset. Unregistered IDs are not
+// checked.
+func (r *Registry) CheckActive(id TxID, height uint64) error {
+	md, ok := r.Metadata(id)
+	if !ok {
+		return nil
+	}
+	if height < md.ValidFrom {
+		return ErrNotYetValid{ID: id, Height: height, ValidFrom: md.ValidFrom}
+	}
+	if md.ValidUntil != 0 && height > md.ValidUntil {
+		err := ErrSunset{
+			ID:          id,
+			Height:      height,
+			ValidUntil:  md.ValidUntil,
+			Deprecation: md.Deprecation,
+		}
+		if successor, ok := r.Registration(md.Successor); ok && md.Successor != 0 {
+			err.Successor = successor.Name
+		}
+		return err
+	}
+	return nil
+}
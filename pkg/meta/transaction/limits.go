@@ -0,0 +1,144 @@
+package metatx
+
+// ----- ---- --- -- -
+// Copyright 2019, 2020 The Axiom Foundation. All Rights Reserved.
+//
+// Licensed under the Apache License 2.0 (the "License").  You may not use
+// this file except in compliance with the License.  You can obtain a copy
+// in the file LICENSE in the source distribution or at
+// https://www.apache.org/licenses/LICENSE-2.0.txt
+// - -- --- ---- -----
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+	"github.com/tinylib/msgp/msgp"
+)
+
+// Limits bound the size and structure of serialized transactions
+//
+// The msgp decoders allocate according to the collection lengths declared in
+// the payload, so a few bytes can demand gigabytes of memory. Checking a
+// payload against Limits before decoding it avoids this.
+//
+// A zero field means that quantity is unlimited, except for MaxDepth: as the
+// check recurses once per level of nesting, depth is always bounded by
+// HardMaxDepth. Even the zero value of Limits therefore rejects payloads
+// nested more deeply than that, although the msgp decoders, which skip
+// unknown fields and keep raw transactables, would accept them.
+type Limits struct {
+	// MaxTxBytes is the maximum length of a serialized transaction
+	MaxTxBytes int
+	// MaxDepth is the maximum nesting depth of maps and arrays, including
+	// the transaction itself. It can't exceed HardMaxDepth.
+	MaxDepth int
+	// MaxCollectionLen is the maximum number of elements of any map or array
+	MaxCollectionLen uint32
+}
+
+// DefaultLimits are generous limits suitable for most chains
+//
+// MaxTxBytes matches tendermint's default mempool max_tx_bytes.
+var DefaultLimits = Limits{
+	MaxTxBytes:       1024 * 1024,
+	MaxDepth:         32,
+	MaxCollectionLen: 16 * 1024,
+}
+
+// HardMaxDepth bounds the nesting depth which CheckLimits accepts, whatever
+// the limits, so that a deeply nested payload can't exhaust the stack
+const HardMaxDepth = 1024
+
+// ErrLimitExceeded is returned when a serialized transaction exceeds its Limits
+type ErrLimitExceeded struct {
+	Limit string
+	Value uint64
+	Max   uint64
+}
+
+func (e ErrLimitExceeded) Error() string {
+	return fmt.Sprintf("transaction exceeds %s limit: %d > %d", e.Limit, e.Value, e.Max)
+}
+
+// CheckLimits walks a serialized transaction, including its transactable,
+// and returns an ErrLimitExceeded if it exceeds the limits
+//
+// It allocates nothing in proportion to the declared sizes within the
+// payload. Malformed payloads produce other errors.
+func CheckLimits(bytes []byte, limits Limits) error {
+	if limits.MaxTxBytes > 0 && len(bytes) > limits.MaxTxBytes {
+		return ErrLimitExceeded{
+			Limit: "size",
+			Value: uint64(len(bytes)),
+			Max:   uint64(limits.MaxTxBytes),
+		}
+	}
+	if limits.MaxDepth <= 0 || limits.MaxDepth > HardMaxDepth {
+		limits.MaxDepth = HardMaxDepth
+	}
+	_, err := checkValueLimits(bytes, limits, 1)
+	return err
+}
+
+// checkValueLimits checks the msgp value at the head of b, and returns the rest
+//
+// limits.MaxDepth must be positive.
+func checkValueLimits(b []byte, limits Limits, depth int) ([]byte, error) {
+	var (
+		n       uint32
+		perElem uint32
+		err     error
+	)
+	switch msgp.NextType(b) {
+	case msgp.MapType:
+		n, b, err = msgp.ReadMapHeaderBytes(b)
+		perElem = 2
+	case msgp.ArrayType:
+		n, b, err = msgp.ReadArrayHeaderBytes(b)
+		perElem = 1
+	default:
+		return msgp.Skip(b)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if depth > limits.MaxDepth {
+		return nil, ErrLimitExceeded{
+			Limit: "depth",
+			Value: uint64(depth),
+			Max:   uint64(limits.MaxDepth),
+		}
+	}
+	if limits.MaxCollectionLen > 0 && n > limits.MaxCollectionLen {
+		return nil, ErrLimitExceeded{
+			Limit: "collection length",
+			Value: uint64(n),
+			Max:   uint64(limits.MaxCollectionLen),
+		}
+	}
+	// every element occupies at least one byte, so this catches absurd
+	// lengths even when collection lengths are unlimited
+	if uint64(n)*uint64(perElem) > uint64(len(b)) {
+		return nil, msgp.ErrShortBytes
+	}
+
+	for i := uint64(0); i < uint64(n)*uint64(perElem); i++ {
+		b, err = checkValueLimits(b, limits, depth+1)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return b, nil
+}
+
+// UnmarshalWithLimits is like Unmarshal, but first checks the serialized
+// transaction against the limits
+func UnmarshalWithLimits(bytes []byte, idMap TxIDMap, limits Limits) (Transactable, error) {
+	err := CheckLimits(bytes, limits)
+	if err != nil {
+		return nil, errors.Wrap(err, "Transaction deserialization failed")
+	}
+	return Unmarshal(bytes, idMap)
+}
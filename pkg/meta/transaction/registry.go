@@ -0,0 +1,247 @@
+package metatx
+
+// ----- ---- --- -- -
+// Copyright 2019, 2020 The Axiom Foundation. All Rights Reserved.
+//
+// Licensed under the Apache License 2.0 (the "License").  You may not use
+// this file except in compliance with the License.  You can obtain a copy
+// in the file LICENSE in the source distribution or at
+// https://www.apache.org/licenses/LICENSE-2.0.txt
+// - -- --- ---- -----
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/pkg/errors"
+)
+
+// A TxIDResolver maps between TxIDs and Transactable types
+//
+// Both TxIDMap and *Registry are TxIDResolvers.
+type TxIDResolver interface {
+	// TxIDOf returns the ID associated with a Transactable's type
+	TxIDOf(txab Transactable) (TxID, error)
+	// Example returns an example object of the Transactable type with this ID
+	Example(id TxID) (Transactable, bool)
+}
+
+var _ TxIDResolver = (TxIDMap)(nil)
+var _ TxIDResolver = (*Registry)(nil)
+
+// TxIDOf implements TxIDResolver
+//
+// It is subject to the limitations of the TxIDOf function. Use a Registry to
+// avoid them.
+func (m TxIDMap) TxIDOf(txab Transactable) (TxID, error) {
+	return TxIDOf(txab, m)
+}
+
+// Example implements TxIDResolver
+func (m TxIDMap) Example(id TxID) (Transactable, bool) {
+	example, ok := m[id]
+	return example, ok
+}
+
+// FullNameOf gets the fully qualified type name of a Transactable,
+// i.e. "github.com/org/repo/pkg.Name"
+func FullNameOf(txab Transactable) string {
+	t := reflect.TypeOf(txab).Elem()
+	return t.PkgPath() + "." + t.Name()
+}
+
+// A Registration describes a Transactable type known to a Registry
+type Registration struct {
+	ID       TxID
+	Name     string
+	FullName string
+	Aliases  []string
+	Example  Transactable
+	Metadata TxMetadata
+}
+
+// A Registry records the Transactable types known to an app and their TxIDs
+//
+// Unlike a TxIDMap, a Registry identifies types by their reflected type
+// rather than by name, so identically-named types from different packages
+// don't collide, and reverse lookups don't require a scan.
+//
+// The zero value is not usable; use NewRegistry.
+type Registry struct {
+	byID       map[TxID]*Registration
+	byType     map[reflect.Type]TxID
+	byFullName map[string]TxID
+	byName     map[string][]TxID
+	byAlias    map[string]TxID
+}
+
+// NewRegistry creates an empty Registry
+func NewRegistry() *Registry {
+	return &Registry{
+		byID:       make(map[TxID]*Registration),
+		byType:     make(map[reflect.Type]TxID),
+		byFullName: make(map[string]TxID),
+		byName:     make(map[string][]TxID),
+		byAlias:    make(map[string]TxID),
+	}
+}
+
+// RegistryFrom creates a Registry from a TxIDMap
+//
+// It returns an error if the map contains types which can't be registered,
+// or registers the same type under several IDs.
+func RegistryFrom(idMap TxIDMap) (*Registry, error) {
+	r := NewRegistry()
+	ids := make([]TxID, 0, len(idMap))
+	for id := range idMap {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	for _, id := range ids {
+		err := r.Register(id, idMap[id])
+		if err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+// Register adds a Transactable type to the registry
+//
+// `example` must be a pointer to an empty instance of the type. Aliases are
+// alternate names by which tooling may look up the type. It is an error
+// to register an ID or a type twice, or to reuse a fully qualified name or
+// alias as an alias.
+func (r *Registry) Register(id TxID, example Transactable, aliases ...string) error {
+	if example == nil {
+		return fmt.Errorf("nil example for TxID %d", id)
+	}
+	t := reflect.TypeOf(example)
+	if t.Kind() != reflect.Ptr {
+		return fmt.Errorf("example for TxID %d must be a pointer; got %s", id, t)
+	}
+	name := NameOf(example)
+	if len(name) == 0 {
+		return errors.New("anonymous types are not Transactable")
+	}
+	if existing, ok := r.byID[id]; ok {
+		return fmt.Errorf("TxID %d is already registered to %s", id, existing.FullName)
+	}
+	if existing, ok := r.byType[t]; ok {
+		return fmt.Errorf("%s is already registered as TxID %d", FullNameOf(example), existing)
+	}
+	if existing, ok := r.lookupExact(FullNameOf(example)); ok {
+		return fmt.Errorf("name %s is already used by TxID %d", FullNameOf(example), existing)
+	}
+
+	reg := Registration{
+		ID:       id,
+		Name:     name,
+		FullName: FullNameOf(example),
+		Aliases:  append([]string{}, aliases...),
+		Example:  example,
+	}
+	for _, alias := range reg.Aliases {
+		if existing, ok := r.lookupExact(alias); ok {
+			return fmt.Errorf("alias %s of TxID %d is already used by TxID %d", alias, id, existing)
+		}
+	}
+
+	r.byID[id] = &reg
+	r.byType[t] = id
+	r.byFullName[reg.FullName] = id
+	r.byName[name] = append(r.byName[name], id)
+	for _, alias := range reg.Aliases {
+		r.byAlias[alias] = id
+	}
+	return nil
+}
+
+// MustRegister is like Register, but panics on error
+//
+// It is intended for registrations in package initialization.
+func (r *Registry) MustRegister(id TxID, example Transactable, aliases ...string) {
+	err := r.Register(id, example, aliases...)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// TxIDOf implements TxIDResolver
+func (r *Registry) TxIDOf(txab Transactable) (TxID, error) {
+	if txab == nil {
+		return 0, errors.New("nil Transactable")
+	}
+	id, ok := r.byType[reflect.TypeOf(txab)]
+	if !ok {
+		return 0, fmt.Errorf("Supplied type `%T` not in registry", txab)
+	}
+	return id, nil
+}
+
+// Example implements TxIDResolver
+func (r *Registry) Example(id TxID) (Transactable, bool) {
+	reg, ok := r.byID[id]
+	if !ok {
+		return nil, false
+	}
+	return reg.Example, true
+}
+
+// Registration returns the registration of a TxID
+func (r *Registry) Registration(id TxID) (Registration, bool) {
+	reg, ok := r.byID[id]
+	if !ok {
+		return Registration{}, false
+	}
+	return *reg, true
+}
+
+// lookupExact finds a TxID by fully qualified name or alias
+func (r *Registry) lookupExact(name string) (TxID, bool) {
+	if id, ok := r.byFullName[name]; ok {
+		return id, true
+	}
+	id, ok := r.byAlias[name]
+	return id, ok
+}
+
+// Lookup finds a TxID by name
+//
+// The name may be a fully qualified type name, an alias, or an unqualified
+// type name. Unqualified names only resolve if exactly one registered type
+// has that name.
+func (r *Registry) Lookup(name string) (TxID, error) {
+	if id, ok := r.lookupExact(name); ok {
+		return id, nil
+	}
+	ids := r.byName[name]
+	switch len(ids) {
+	case 0:
+		return 0, fmt.Errorf("no Transactable named %s", name)
+	case 1:
+		return ids[0], nil
+	default:
+		return 0, fmt.Errorf("%s is ambiguous: it names TxIDs %v", name, ids)
+	}
+}
+
+// Registrations lists all registered types, in order of TxID
+func (r *Registry) Registrations() []Registration {
+	regs := make([]Registration, 0, len(r.byID))
+	for _, reg := range r.byID {
+		regs = append(regs, *reg)
+	}
+	sort.Slice(regs, func(i, j int) bool { return regs[i].ID < regs[j].ID })
+	return regs
+}
+
+// TxIDMap converts the registry into a TxIDMap
+func (r *Registry) TxIDMap() TxIDMap {
+	idMap := make(TxIDMap, len(r.byID))
+	for id, reg := range r.byID {
+		idMap[id] = reg.Example
+	}
+	return idMap
+}
@@ -0,0 +1,90 @@
+//go:build go1.18
+
+package tests
+
+// ----- ---- --- -- -
+// Copyright 2019, 2020 The Axiom Foundation. All Rights Reserved.
+//
+// Licensed under the Apache License 2.0 (the "License").  You may not use
+// this file except in compliance with the License.  You can obtain a copy
+// in the file LICENSE in the source distribution or at
+// https://www.apache.org/licenses/LICENSE-2.0.txt
+// - -- --- ---- -----
+
+import (
+	"testing"
+
+	tx "github.com/ndau/metanode/pkg/meta/transaction"
+	"github.com/stretchr/testify/require"
+)
+
+// addSeeds seeds a fuzzer with valid and slightly damaged transactions
+func addSeeds(f *testing.F) {
+	for _, txab := range []tx.Transactable{
+		&Stringy{},
+		&Stringy{S: "foo bar bat"},
+		&Inty{},
+		&Inty{I: -1},
+	} {
+		b, err := tx.Marshal(txab, Tmap)
+		require.NoError(f, err)
+		f.Add(b)
+		f.Add(b[:len(b)/2])
+		f.Add(append(b, 0xc0))
+	}
+	f.Add([]byte{})
+	f.Add([]byte{0x83})
+}
+
+func FuzzUnmarshal(f *testing.F) {
+	addSeeds(f)
+	f.Fuzz(func(t *testing.T, data []byte) {
+		txab, err := tx.Unmarshal(data, Tmap)
+		if err != nil {
+			require.Nil(t, txab)
+			return
+		}
+
+		// anything which decodes must survive a round trip
+		reencoded, err := tx.Marshal(txab, Tmap)
+		require.NoError(t, err)
+		again, err := tx.Unmarshal(reencoded, Tmap)
+		require.NoError(t, err)
+		require.Equal(t, txab, again)
+		require.Equal(t, txab.SignableBytes(), again.SignableBytes())
+
+		// limits only ever reject more; even the zero limits reject nesting
+		// beyond the hard depth cap, which the decoders accept
+		_, err = tx.UnmarshalWithLimits(data, Tmap, tx.Limits{})
+		requireWithinHardLimits(t, err)
+	})
+}
+
+func FuzzTransactionUnmarshalMsg(f *testing.F) {
+	addSeeds(f)
+	f.Fuzz(func(t *testing.T, data []byte) {
+		txn := tx.Transaction{}
+		_, err := txn.UnmarshalMsg(data)
+		if err != nil {
+			return
+		}
+
+		// anything which decodes is well-formed msgp, though it may be nested
+		// beyond the hard depth cap
+		requireWithinHardLimits(t, tx.CheckLimits(data, tx.Limits{}))
+
+		reencoded, err := txn.MarshalMsg(nil)
+		require.NoError(t, err)
+		decoded := tx.Transaction{}
+		rest, err := decoded.UnmarshalMsg(reencoded)
+		require.NoError(t, err)
+		require.Empty(t, rest)
+		require.Equal(t, txn, decoded)
+
+		// decoding the transactable must fail cleanly or succeed
+		txab, err := txn.AsTransactable(Tmap)
+		if err == nil {
+			require.NotNil(t, txab)
+		}
+	})
+}
@@ -0,0 +1,96 @@
+package tests
+
+// ----- ---- --- -- -
+// Copyright 2019, 2020 The Axiom Foundation. All Rights Reserved.
+//
+// Licensed under the Apache License 2.0 (the "License").  You may not use
+// this file except in compliance with the License.  You can obtain a copy
+// in the file LICENSE in the source distribution or at
+// https://www.apache.org/licenses/LICENSE-2.0.txt
+// - -- --- ---- -----
+
+import (
+	"encoding/json"
+	"testing"
+
+	tx "github.com/ndau/metanode/pkg/meta/transaction"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJSONRoundtripPreservesSignableBytes(t *testing.T) {
+	r, err := tx.RegistryFrom(jsonTmap)
+	require.NoError(t, err)
+
+	for _, txab := range []tx.Transactable{
+		&Stringy{S: "foo bar bat"},
+		&Inty{I: 12345},
+		&Hexy{B: []byte{0xde, 0xad, 0xbe, 0xef}},
+	} {
+		msgpBytes, err := tx.MarshalWithResolver(txab, r)
+		require.NoError(t, err)
+		txn := tx.Transaction{}
+		_, err = txn.UnmarshalMsg(msgpBytes)
+		require.NoError(t, err)
+
+		jsonBytes, err := txn.ToJSON(r)
+		require.NoError(t, err)
+
+		// JSON -> msgp must reproduce the original transaction exactly
+		fromJSON, err := tx.TransactionFromJSON(jsonBytes, r)
+		require.NoError(t, err)
+		reencoded, err := fromJSON.MarshalMsg(nil)
+		require.NoError(t, err)
+		require.Equal(t, msgpBytes, reencoded)
+
+		decoded, err := tx.UnmarshalJSON(jsonBytes, r)
+		require.NoError(t, err)
+		require.Equal(t, txab.SignableBytes(), decoded.SignableBytes())
+		require.Equal(t, tx.Hash(txab), tx.Hash(decoded))
+
+		// the encoding is canonical
+		again, err := fromJSON.ToJSON(r)
+		require.NoError(t, err)
+		require.Equal(t, jsonBytes, again)
+	}
+}
+
+func TestJSONRendersTypeNames(t *testing.T) {
+	r := tx.NewRegistry()
+	require.NoError(t, r.Register(1, &Stringy{}))
+	require.NoError(t, r.Register(2, &Hexy{}, "hex"))
+
+	for _, tc := range []struct {
+		txab   tx.Transactable
+		name   string
+		expect string
+	}{
+		{&Stringy{S: "foo"}, "Stringy", `{"S":"foo"}`},
+		{&Hexy{B: []byte{0xff}}, "hex", `"ff"`},
+	} {
+		jsonBytes, err := tx.MarshalJSON(tc.txab, r)
+		require.NoError(t, err)
+
+		var fields map[string]json.RawMessage
+		require.NoError(t, json.Unmarshal(jsonBytes, &fields))
+		require.Len(t, fields, 3)
+		require.Contains(t, fields, "nonce")
+		require.Equal(t, `"`+tc.name+`"`, string(fields["type"]))
+		require.JSONEq(t, tc.expect, string(fields["transactable"]))
+	}
+}
+
+func TestJSONRejectsMalformedTransactions(t *testing.T) {
+	r, err := tx.RegistryFrom(jsonTmap)
+	require.NoError(t, err)
+
+	for _, data := range []string{
+		`{"nonce":"","type":"Unknown","transactable":{}}`,
+		`{"nonce":"","type":"Stringy","transactable":{"Q":"unknown field"}}`,
+		`{"nonce":"","type":"Stringy","transactable":{"S":"foo"},"extra":1}`,
+		`{"nonce":"","type":"Hexy","transactable":"not hex"}`,
+		`{"nonce":"","type":"Stringy","transactable":{"S":"foo"}} {}`,
+	} {
+		_, err := tx.UnmarshalJSON([]byte(data), r)
+		require.Error(t, err, data)
+	}
+}
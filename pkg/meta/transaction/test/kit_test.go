@@ -0,0 +1,61 @@
+package tests
+
+// ----- ---- --- -- -
+// Copyright 2019, 2020 The Axiom Foundation. All Rights Reserved.
+//
+// Licensed under the Apache License 2.0 (the "License").  You may not use
+// this file except in compliance with the License.  You can obtain a copy
+// in the file LICENSE in the source distribution or at
+// https://www.apache.org/licenses/LICENSE-2.0.txt
+// - -- --- ---- -----
+
+import (
+	"math/rand"
+	"testing"
+
+	tx "github.com/ndau/metanode/pkg/meta/transaction"
+	"github.com/ndau/metanode/pkg/meta/transaction/txtest"
+)
+
+func randomBytes(r *rand.Rand) []byte {
+	b := make([]byte, r.Intn(16))
+	r.Read(b)
+	return b
+}
+
+func randomString(r *rand.Rand) string {
+	return string(randomBytes(r))
+}
+
+func TestTransactableConformance(t *testing.T) {
+	txIDs := tx.TxIDMap{
+		tx.TxID(1): &Stringy{},
+		tx.TxID(2): &Inty{},
+		tx.TxID(3): &Hexy{},
+		tx.TxID(4): &Signed{},
+	}
+	txtest.Kit{
+		TxIDs: txIDs,
+		Generators: map[tx.TxID]txtest.Generator{
+			1: func(r *rand.Rand) tx.Transactable {
+				return &Stringy{S: randomString(r)}
+			},
+			2: func(r *rand.Rand) tx.Transactable {
+				return &Inty{I: r.Int() - r.Int()}
+			},
+			3: func(r *rand.Rand) tx.Transactable {
+				return &Hexy{B: randomBytes(r)}
+			},
+			4: func(r *rand.Rand) tx.Transactable {
+				return &Signed{S: randomString(r), Signature: randomBytes(r)}
+			},
+		},
+		Resigners: map[tx.TxID]txtest.Resigner{
+			4: func(txab tx.Transactable, r *rand.Rand) tx.Transactable {
+				signed := *txab.(*Signed)
+				signed.Signature = append(append([]byte(nil), signed.Signature...), byte(r.Intn(256)))
+				return &signed
+			},
+		},
+	}.Run(t)
+}
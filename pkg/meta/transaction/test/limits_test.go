@@ -0,0 +1,135 @@
+package tests
+
+// ----- ---- --- -- -
+// Copyright 2019, 2020 The Axiom Foundation. All Rights Reserved.
+//
+// Licensed under the Apache License 2.0 (the "License").  You may not use
+// this file except in compliance with the License.  You can obtain a copy
+// in the file LICENSE in the source distribution or at
+// https://www.apache.org/licenses/LICENSE-2.0.txt
+// - -- --- ---- -----
+
+import (
+	"testing"
+
+	tx "github.com/ndau/metanode/pkg/meta/transaction"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"github.com/tinylib/msgp/msgp"
+)
+
+// txWithRaw wraps arbitrary msgp as the transactable of a transaction
+func txWithRaw(t *testing.T, raw []byte) []byte {
+	txn := tx.Transaction{
+		Nonce:          []byte("nonce"),
+		TransactableID: 1,
+		Transactable:   raw,
+	}
+	b, err := txn.MarshalMsg(nil)
+	require.NoError(t, err)
+	return b
+}
+
+func nested(depth int) []byte {
+	var b []byte
+	for i := 0; i < depth; i++ {
+		b = msgp.AppendArrayHeader(b, 1)
+	}
+	return msgp.AppendNil(b)
+}
+
+func requireLimitExceeded(t *testing.T, err error, limit string) {
+	require.Error(t, err)
+	lerr, ok := err.(tx.ErrLimitExceeded)
+	require.True(t, ok, "expected ErrLimitExceeded; got %T: %s", err, err)
+	require.Equal(t, limit, lerr.Limit)
+}
+
+// requireWithinHardLimits requires that err is nil, or reports nesting
+// beyond tx.HardMaxDepth: the only thing the zero Limits reject
+func requireWithinHardLimits(t *testing.T, err error) {
+	if err == nil {
+		return
+	}
+	lerr, ok := errors.Cause(err).(tx.ErrLimitExceeded)
+	require.True(t, ok, "expected nil or ErrLimitExceeded; got %T: %s", err, err)
+	require.Equal(t, "depth", lerr.Limit)
+	require.Equal(t, uint64(tx.HardMaxDepth), lerr.Max)
+}
+
+func TestOrdinaryTxsAreWithinLimits(t *testing.T) {
+	b, err := tx.Marshal(&Stringy{S: "foo bar bat"}, Tmap)
+	require.NoError(t, err)
+	require.NoError(t, tx.CheckLimits(b, tx.DefaultLimits))
+
+	txab, err := tx.UnmarshalWithLimits(b, Tmap, tx.DefaultLimits)
+	require.NoError(t, err)
+	require.Equal(t, &Stringy{S: "foo bar bat"}, txab)
+}
+
+func TestTxSizeLimit(t *testing.T) {
+	b, err := tx.Marshal(&Stringy{S: "foo bar bat"}, Tmap)
+	require.NoError(t, err)
+
+	limits := tx.Limits{MaxTxBytes: len(b)}
+	require.NoError(t, tx.CheckLimits(b, limits))
+	limits.MaxTxBytes--
+	requireLimitExceeded(t, tx.CheckLimits(b, limits), "size")
+
+	_, err = tx.UnmarshalWithLimits(b, Tmap, limits)
+	require.Error(t, err)
+}
+
+func TestTxDepthLimit(t *testing.T) {
+	// the transaction itself is at depth 1
+	b := txWithRaw(t, nested(3))
+	require.NoError(t, tx.CheckLimits(b, tx.Limits{MaxDepth: 4}))
+	requireLimitExceeded(t, tx.CheckLimits(b, tx.Limits{MaxDepth: 3}), "depth")
+
+	requireLimitExceeded(t, tx.CheckLimits(txWithRaw(t, nested(1000)), tx.DefaultLimits), "depth")
+
+	// depth is bounded even when the limits don't bound it
+	deep := txWithRaw(t, nested(100000))
+	requireLimitExceeded(t, tx.CheckLimits(deep, tx.Limits{}), "depth")
+	requireLimitExceeded(t, tx.CheckLimits(deep, tx.Limits{MaxDepth: 1 << 30}), "depth")
+}
+
+func TestHardDepthCapIsStricterThanDecoding(t *testing.T) {
+	// 1025 nested arrays, below the transaction itself
+	b := txWithRaw(t, nested(tx.HardMaxDepth+1))
+
+	txn := tx.Transaction{}
+	rest, err := txn.UnmarshalMsg(b)
+	require.NoError(t, err)
+	require.Empty(t, rest)
+
+	err = tx.CheckLimits(b, tx.Limits{})
+	requireLimitExceeded(t, err, "depth")
+	requireWithinHardLimits(t, err)
+
+	// the transaction itself is at depth 1
+	require.NoError(t, tx.CheckLimits(txWithRaw(t, nested(tx.HardMaxDepth-1)), tx.Limits{}))
+	requireLimitExceeded(t, tx.CheckLimits(txWithRaw(t, nested(tx.HardMaxDepth)), tx.Limits{}), "depth")
+}
+
+func TestTxCollectionLimit(t *testing.T) {
+	raw := msgp.AppendArrayHeader(nil, 5)
+	for i := 0; i < 5; i++ {
+		raw = msgp.AppendInt(raw, i)
+	}
+	b := txWithRaw(t, raw)
+	require.NoError(t, tx.CheckLimits(b, tx.Limits{MaxCollectionLen: 5}))
+	requireLimitExceeded(t, tx.CheckLimits(b, tx.Limits{MaxCollectionLen: 4}), "collection length")
+}
+
+func TestTxDeclaredLengthsAreChecked(t *testing.T) {
+	// a few bytes declaring an enormous map
+	b := txWithRaw(t, msgp.AppendMapHeader(nil, 1<<31))
+	requireLimitExceeded(t, tx.CheckLimits(b, tx.DefaultLimits), "collection length")
+
+	// even without a collection limit, the declaration can't exceed the payload
+	err := tx.CheckLimits(b, tx.Limits{})
+	require.Error(t, err)
+	_, isLimit := err.(tx.ErrLimitExceeded)
+	require.False(t, isLimit)
+}
@@ -0,0 +1,40 @@
+// Package other defines a Transactable whose name collides with one in the
+// tests package, to exercise the transactable registry.
+package other
+
+// ----- ---- --- -- -
+// Copyright 2019, 2020 The Axiom Foundation. All Rights Reserved.
+//
+// Licensed under the Apache License 2.0 (the "License").  You may not use
+// this file except in compliance with the License.  You can obtain a copy
+// in the file LICENSE in the source distribution or at
+// https://www.apache.org/licenses/LICENSE-2.0.txt
+// - -- --- ---- -----
+
+import (
+	tx "github.com/ndau/metanode/pkg/meta/transaction"
+)
+
+//go:generate msgp -tests=0
+
+var _ tx.Transactable = (*Stringy)(nil)
+
+// Stringy has the same name as tests.Stringy, but is a different type
+type Stringy struct {
+	S string
+}
+
+// Validate implements Transactable
+func (Stringy) Validate(interface{}) error {
+	return nil
+}
+
+// Apply implements Transactable
+func (Stringy) Apply(interface{}) error {
+	return nil
+}
+
+// SignableBytes implements Transactable
+func (s Stringy) SignableBytes() []byte {
+	return []byte(s.S)
+}
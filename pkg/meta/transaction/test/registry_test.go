@@ -0,0 +1,127 @@
+package tests
+
+// ----- ---- --- -- -
+// Copyright 2019, 2020 The Axiom Foundation. All Rights Reserved.
+//
+// Licensed under the Apache License 2.0 (the "License").  You may not use
+// this file except in compliance with the License.  You can obtain a copy
+// in the file LICENSE in the source distribution or at
+// https://www.apache.org/licenses/LICENSE-2.0.txt
+// - -- --- ---- -----
+
+import (
+	"testing"
+
+	tx "github.com/ndau/metanode/pkg/meta/transaction"
+	"github.com/ndau/metanode/pkg/meta/transaction/test/other"
+	"github.com/stretchr/testify/require"
+)
+
+// Renamed is a Transactable distinct from those registered by makeRegistry
+type Renamed struct {
+	Inty
+}
+
+func makeRegistry(t *testing.T) *tx.Registry {
+	r := tx.NewRegistry()
+	require.NoError(t, r.Register(1, &Stringy{}, "str"))
+	require.NoError(t, r.Register(2, &Inty{}))
+	require.NoError(t, r.Register(3, &other.Stringy{}))
+	return r
+}
+
+func TestRegistryDistinguishesSameNamedTypes(t *testing.T) {
+	r := makeRegistry(t)
+
+	id, err := r.TxIDOf(&Stringy{})
+	require.NoError(t, err)
+	require.Equal(t, tx.TxID(1), id)
+	id, err = r.TxIDOf(&other.Stringy{})
+	require.NoError(t, err)
+	require.Equal(t, tx.TxID(3), id)
+
+	// a TxIDMap can't tell them apart
+	idMap := r.TxIDMap()
+	sid, err := idMap.TxIDOf(&Stringy{})
+	require.NoError(t, err)
+	oid, err := idMap.TxIDOf(&other.Stringy{})
+	require.NoError(t, err)
+	require.Equal(t, sid, oid)
+}
+
+func TestRegistryRejectsCollisions(t *testing.T) {
+	r := makeRegistry(t)
+
+	// duplicate ID
+	require.Error(t, r.Register(1, &Renamed{}))
+	// duplicate type
+	require.Error(t, r.Register(4, &Inty{}))
+	// duplicate alias
+	require.Error(t, r.Register(4, &Renamed{}, "str"))
+	// alias shadowing a fully qualified name
+	require.Error(t, r.Register(4, &Renamed{}, "github.com/ndau/metanode/pkg/meta/transaction/test.Inty"))
+	// nil example
+	require.Error(t, r.Register(4, nil))
+	// anonymous types
+	require.Error(t, r.Register(4, &struct{ Inty }{}))
+
+	require.Len(t, r.Registrations(), 3)
+	require.NoError(t, r.Register(4, &Renamed{}, "renamed"))
+	require.Panics(t, func() { r.MustRegister(1, &Inty{}) })
+}
+
+func TestRegistryLookup(t *testing.T) {
+	r := makeRegistry(t)
+
+	for name, expect := range map[string]tx.TxID{
+		"str":  1,
+		"Inty": 2,
+		"github.com/ndau/metanode/pkg/meta/transaction/test.Stringy":       1,
+		"github.com/ndau/metanode/pkg/meta/transaction/test/other.Stringy": 3,
+	} {
+		id, err := r.Lookup(name)
+		require.NoError(t, err, name)
+		require.Equal(t, expect, id, name)
+	}
+
+	// unqualified names must be unambiguous
+	_, err := r.Lookup("Stringy")
+	require.Error(t, err)
+	_, err = r.Lookup("Unknown")
+	require.Error(t, err)
+}
+
+func TestRegistryLists(t *testing.T) {
+	r := makeRegistry(t)
+
+	regs := r.Registrations()
+	require.Len(t, regs, 3)
+	for i, reg := range regs {
+		require.Equal(t, tx.TxID(i+1), reg.ID)
+		example, ok := r.Example(reg.ID)
+		require.True(t, ok)
+		require.Equal(t, example, reg.Example)
+	}
+	require.Equal(t, "Stringy", regs[2].Name)
+	require.Equal(t, "github.com/ndau/metanode/pkg/meta/transaction/test/other.Stringy", regs[2].FullName)
+	require.Equal(t, []string{"str"}, regs[0].Aliases)
+}
+
+func TestRegistryRoundtrip(t *testing.T) {
+	r := makeRegistry(t)
+
+	b, err := tx.MarshalWithResolver(&other.Stringy{S: "foo"}, r)
+	require.NoError(t, err)
+	txab, err := tx.UnmarshalWithResolver(b, r)
+	require.NoError(t, err)
+	require.Equal(t, &other.Stringy{S: "foo"}, txab)
+}
+
+func TestRegistryFromTxIDMap(t *testing.T) {
+	r, err := tx.RegistryFrom(Tmap)
+	require.NoError(t, err)
+	require.Equal(t, tx.TxIDMap(Tmap), r.TxIDMap())
+
+	_, err = tx.RegistryFrom(tx.TxIDMap{1: &Inty{}, 2: &Inty{}})
+	require.Error(t, err)
+}
@@ -12,6 +12,8 @@ package tests
 
 import (
 	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
 
 	tx "github.com/ndau/metanode/pkg/meta/transaction"
@@ -65,3 +67,63 @@ var Tmap = map[tx.TxID]tx.Transactable{
 	tx.TxID(1): &Stringy{},
 	tx.TxID(2): &Inty{},
 }
+
+var _ tx.Transactable = (*Hexy)(nil)
+
+// Hexy controls its own JSON encoding
+type Hexy struct {
+	B []byte
+}
+
+func (Hexy) Validate(interface{}) error {
+	return nil
+}
+
+func (Hexy) Apply(interface{}) error {
+	return nil
+}
+
+func (h Hexy) SignableBytes() []byte {
+	return h.B
+}
+
+func (h Hexy) MarshalJSON() ([]byte, error) {
+	return json.Marshal(hex.EncodeToString(h.B))
+}
+
+func (h *Hexy) UnmarshalJSON(data []byte) error {
+	var s string
+	err := json.Unmarshal(data, &s)
+	if err != nil {
+		return err
+	}
+	h.B, err = hex.DecodeString(s)
+	return err
+}
+
+// jsonTmap extends Tmap with a type which controls its own JSON encoding
+var jsonTmap = tx.TxIDMap{
+	tx.TxID(1): &Stringy{},
+	tx.TxID(2): &Inty{},
+	tx.TxID(3): &Hexy{},
+}
+
+var _ tx.Transactable = (*Signed)(nil)
+
+// Signed has a signature, which its signable bytes exclude
+type Signed struct {
+	S         string
+	Signature []byte
+}
+
+func (Signed) Validate(interface{}) error {
+	return nil
+}
+
+func (Signed) Apply(interface{}) error {
+	return nil
+}
+
+func (s Signed) SignableBytes() []byte {
+	return []byte(s.S)
+}
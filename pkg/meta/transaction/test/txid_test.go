@@ -0,0 +1,76 @@
+package tests
+
+// ----- ---- --- -- -
+// Copyright 2019, 2020 The Axiom Foundation. All Rights Reserved.
+//
+// Licensed under the Apache License 2.0 (the "License").  You may not use
+// this file except in compliance with the License.  You can obtain a copy
+// in the file LICENSE in the source distribution or at
+// https://www.apache.org/licenses/LICENSE-2.0.txt
+// - -- --- ---- -----
+
+import (
+	"testing"
+
+	tx "github.com/ndau/metanode/pkg/meta/transaction"
+	"github.com/stretchr/testify/require"
+	"github.com/tinylib/msgp/msgp"
+)
+
+// legacyEncode encodes a transaction as nodes did when TxIDs were uint8
+func legacyEncode(t *testing.T, nonce []byte, id uint8, txab tx.Transactable) []byte {
+	txabBytes, err := txab.MarshalMsg(nil)
+	require.NoError(t, err)
+
+	b := msgp.AppendMapHeader(nil, 3)
+	b = msgp.AppendString(b, "Nonce")
+	b = msgp.AppendBytes(b, nonce)
+	b = msgp.AppendString(b, "TransactableID")
+	b = msgp.AppendUint8(b, id)
+	b = msgp.AppendString(b, "Transactable")
+	return append(b, txabBytes...)
+}
+
+func TestLegacyTxIDsAreWireCompatible(t *testing.T) {
+	nonce := []byte("a nonce")
+	sy := Stringy{S: "foo bar bat"}
+
+	// 1 fits in a fixint; 200 doesn't
+	for _, id := range []uint8{1, 200} {
+		r := tx.NewRegistry()
+		require.NoError(t, r.Register(tx.TxID(id), &Stringy{}))
+
+		legacy := legacyEncode(t, nonce, id, &sy)
+
+		// historical transactions still decode
+		txab, err := tx.UnmarshalWithResolver(legacy, r)
+		require.NoError(t, err)
+		require.Equal(t, &sy, txab)
+
+		// and re-encode to the same bytes
+		txn := tx.Transaction{}
+		_, err = txn.UnmarshalMsg(legacy)
+		require.NoError(t, err)
+		require.Equal(t, tx.TxID(id), txn.TransactableID)
+		require.True(t, txn.TransactableID.IsLegacy())
+		reencoded, err := txn.MarshalMsg(nil)
+		require.NoError(t, err)
+		require.Equal(t, legacy, reencoded)
+	}
+}
+
+func TestWideTxIDs(t *testing.T) {
+	id := tx.TxID(70000)
+	require.False(t, id.IsLegacy())
+
+	r := tx.NewRegistry()
+	require.NoError(t, r.Register(id, &Stringy{}))
+	require.NoError(t, r.Register(tx.TxID(uint8(id)), &Inty{}))
+
+	sy := Stringy{S: "foo bar bat"}
+	b, err := tx.MarshalWithResolver(&sy, r)
+	require.NoError(t, err)
+	txab, err := tx.UnmarshalWithResolver(b, r)
+	require.NoError(t, err)
+	require.Equal(t, &sy, txab)
+}
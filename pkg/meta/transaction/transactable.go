@@ -95,11 +95,17 @@ type Transactable interface {
 
 // AsTransaction builds a Transaction from any Transactable
 func AsTransaction(txab Transactable, idMap TxIDMap) (*Transaction, error) {
+	return AsTransactionWithResolver(txab, idMap)
+}
+
+// AsTransactionWithResolver is like AsTransaction, but looks up the id of
+// the Transactable in any TxIDResolver, such as a *Registry
+func AsTransactionWithResolver(txab Transactable, idMap TxIDResolver) (*Transaction, error) {
 	bytes, err := txab.MarshalMsg(nil)
 	if err != nil {
 		return nil, err
 	}
-	id, err := TxIDOf(txab, idMap)
+	id, err := idMap.TxIDOf(txab)
 	if err != nil {
 		return nil, err
 	}
@@ -134,7 +140,13 @@ func Clone(original Transactable) Transactable {
 
 // AsTransactable converts a Transaction into a Transactable instance
 func (tx *Transaction) AsTransactable(idMap TxIDMap) (Transactable, error) {
-	instance, knownType := idMap[tx.TransactableID]
+	return tx.AsTransactableWithResolver(idMap)
+}
+
+// AsTransactableWithResolver is like AsTransactable, but looks up the
+// example Transactable in any TxIDResolver, such as a *Registry
+func (tx *Transaction) AsTransactableWithResolver(idMap TxIDResolver) (Transactable, error) {
+	instance, knownType := idMap.Example(tx.TransactableID)
 	if !knownType {
 		return nil, fmt.Errorf("Unknown TransactableID: %d", tx.TransactableID)
 	}
@@ -146,6 +158,12 @@ func (tx *Transaction) AsTransactable(idMap TxIDMap) (Transactable, error) {
 
 // Unmarshal constructs a Transactable from a serialized Transaction
 func Unmarshal(bytes []byte, idMap TxIDMap) (Transactable, error) {
+	return UnmarshalWithResolver(bytes, idMap)
+}
+
+// UnmarshalWithResolver is like Unmarshal, but resolves the transaction id
+// with any TxIDResolver, such as a *Registry
+func UnmarshalWithResolver(bytes []byte, idMap TxIDResolver) (Transactable, error) {
 	txn := Transaction{}
 	leftovers, err := txn.UnmarshalMsg(bytes)
 	if err != nil {
@@ -154,12 +172,18 @@ func Unmarshal(bytes []byte, idMap TxIDMap) (Transactable, error) {
 	if len(leftovers) > 0 {
 		return nil, errors.New("Transaction deserialization produced leftover bytes")
 	}
-	return txn.AsTransactable(idMap)
+	return txn.AsTransactableWithResolver(idMap)
 }
 
 // Marshal serializes a Transactable into a byte slice
 func Marshal(txab Transactable, idMap TxIDMap) ([]byte, error) {
-	tx, err := AsTransaction(txab, idMap)
+	return MarshalWithResolver(txab, idMap)
+}
+
+// MarshalWithResolver is like Marshal, but resolves the transaction id
+// with any TxIDResolver, such as a *Registry
+func MarshalWithResolver(txab Transactable, idMap TxIDResolver) ([]byte, error) {
+	tx, err := AsTransactionWithResolver(txab, idMap)
 	if err != nil {
 		return nil, err
 	}
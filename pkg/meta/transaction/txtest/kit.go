@@ -0,0 +1,200 @@
+// ----- ---- --- -- -
+// Copyright 2019, 2020 The Axiom Foundation. All Rights Reserved.
+//
+// Licensed under the Apache License 2.0 (the "License").  You may not use
+// this file except in compliance with the License.  You can obtain a copy
+// in the file LICENSE in the source distribution or at
+// https://www.apache.org/licenses/LICENSE-2.0.txt
+// - -- --- ---- -----
+
+// Package txtest checks that Transactable implementations obey the contract
+// documented on metatx.Transactable.
+//
+// That contract can't be enforced by the compiler; violating it produces
+// weird, hard-to-debug errors eventually. Running a Kit in each chain's
+// tests catches the violations early.
+package txtest
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand"
+	"reflect"
+	"sort"
+	"testing"
+
+	metatx "github.com/ndau/metanode/pkg/meta/transaction"
+	"github.com/stretchr/testify/require"
+)
+
+// A Generator returns a Transactable whose contents are chosen using r
+//
+// It must be deterministic: the same sequence of random numbers must produce
+// an equal Transactable. It should produce both valid and invalid instances.
+type Generator func(r *rand.Rand) metatx.Transactable
+
+// A Resigner returns a copy of a Transactable with a different signature
+//
+// It must not modify its input.
+type Resigner func(txab metatx.Transactable, r *rand.Rand) metatx.Transactable
+
+// An ApplyFixture provides apps against which to apply Transactables
+type ApplyFixture struct {
+	// New returns a fresh app, ready for transactables to be applied to it
+	New func(t *testing.T) interface{}
+	// Snapshot returns a representation of the app's state, such as its
+	// noms hash. Equal states must have equal snapshots.
+	Snapshot func(t *testing.T, app interface{}) []byte
+}
+
+// A Kit checks a set of Transactable types
+type Kit struct {
+	// TxIDs are the types to check
+	TxIDs metatx.TxIDMap
+	// Generators must contain a generator for every type in TxIDs
+	Generators map[metatx.TxID]Generator
+	// Resigners must contain a resigner for every type with a signature
+	Resigners map[metatx.TxID]Resigner
+	// Fixture, if set, is used to check that failing Apply calls leave the
+	// app's state unchanged
+	Fixture *ApplyFixture
+	// Iterations is the number of transactables generated per type.
+	// 0 means 100.
+	Iterations int
+	// Seed is the seed of the first iteration. 0 means 1.
+	Seed int64
+}
+
+// Run checks every type of the kit
+//
+// Each type is checked in its own subtest, in which:
+//
+//   - serializing a transactable with metatx.Marshal and deserializing it
+//     with metatx.Unmarshal produces an identical transactable
+//   - SignableBytes is deterministic
+//   - for types with a resigner, SignableBytes excludes the signature
+//   - for types without, SignableBytes includes all data
+//   - transactables decode into a metatx.Clone of the example without
+//     affecting the example
+//   - if the kit has a fixture, a failing Apply of a valid transactable
+//     leaves the state unchanged
+func (k Kit) Run(t *testing.T) {
+	if k.Iterations == 0 {
+		k.Iterations = 100
+	}
+	if k.Seed == 0 {
+		k.Seed = 1
+	}
+
+	ids := make([]metatx.TxID, 0, len(k.TxIDs))
+	for id := range k.TxIDs {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	for _, id := range ids {
+		id := id
+		example := k.TxIDs[id]
+		t.Run(fmt.Sprintf("%d-%s", id, reflect.Indirect(reflect.ValueOf(example)).Type().Name()), func(t *testing.T) {
+			gen, ok := k.Generators[id]
+			require.True(t, ok, "no generator for TxID %d", id)
+
+			seen := make(map[string]string)
+			for i := 0; i < k.Iterations; i++ {
+				seed := k.Seed + int64(i)
+				ok := t.Run(fmt.Sprintf("seed=%d", seed), func(t *testing.T) {
+					txab := gen(rand.New(rand.NewSource(seed)))
+					require.NotNil(t, txab, "generator returned nil")
+					require.Equal(t, reflect.TypeOf(example), reflect.TypeOf(txab), "generator returned the wrong type")
+
+					k.checkRoundTrip(t, id, txab)
+					k.checkClone(t, example, txab)
+					k.checkSignableBytes(t, id, txab, gen(rand.New(rand.NewSource(seed))), seed, seen)
+					k.checkApply(t, txab)
+				})
+				if !ok {
+					return
+				}
+			}
+		})
+	}
+}
+
+func msgpOf(t *testing.T, txab metatx.Transactable) []byte {
+	b, err := txab.MarshalMsg(nil)
+	require.NoError(t, err)
+	return b
+}
+
+func (k Kit) checkRoundTrip(t *testing.T, id metatx.TxID, txab metatx.Transactable) {
+	serialized, err := metatx.Marshal(txab, k.TxIDs)
+	require.NoError(t, err)
+	decoded, err := metatx.Unmarshal(serialized, k.TxIDs)
+	require.NoError(t, err)
+
+	decodedID, err := k.TxIDs.TxIDOf(decoded)
+	require.NoError(t, err)
+	require.Equal(t, id, decodedID)
+	require.Equal(t, msgpOf(t, txab), msgpOf(t, decoded), "round trip changed the transactable")
+	require.Equal(t, txab.SignableBytes(), decoded.SignableBytes(), "round trip changed the signable bytes")
+}
+
+// checkSignableBytes checks txab against an equal instance, again, and
+// against the previously seen instances
+func (k Kit) checkSignableBytes(
+	t *testing.T,
+	id metatx.TxID,
+	txab, again metatx.Transactable,
+	seed int64,
+	seen map[string]string,
+) {
+	sb := txab.SignableBytes()
+	require.Equal(t, sb, txab.SignableBytes(), "SignableBytes is nondeterministic")
+	require.Equal(t, sb, again.SignableBytes(), "equal transactables have different SignableBytes")
+
+	if resign, ok := k.Resigners[id]; ok {
+		before := msgpOf(t, txab)
+		resigned := resign(txab, rand.New(rand.NewSource(-seed)))
+		require.Equal(t, before, msgpOf(t, txab), "resigner modified its input")
+		require.False(t, bytes.Equal(before, msgpOf(t, resigned)), "resigner didn't change the signature")
+		require.Equal(t, sb, resigned.SignableBytes(), "SignableBytes includes the signature")
+		return
+	}
+
+	// without a signature, distinct transactables have distinct signable bytes
+	data := string(msgpOf(t, txab))
+	if other, ok := seen[string(sb)]; ok {
+		require.Equal(t, other, data, "SignableBytes omits some data: distinct transactables have equal SignableBytes")
+	}
+	seen[string(sb)] = data
+}
+
+// checkClone checks that txab can be decoded into a clone of the example
+// without affecting the example
+func (k Kit) checkClone(t *testing.T, example, txab metatx.Transactable) {
+	exampleBytes := msgpOf(t, example)
+	clone := metatx.Clone(example)
+	require.NotNil(t, clone)
+	require.Equal(t, reflect.TypeOf(example), reflect.TypeOf(clone), "Clone changed the type")
+
+	txabBytes := msgpOf(t, txab)
+	_, err := clone.UnmarshalMsg(txabBytes)
+	require.NoError(t, err)
+	require.Equal(t, txabBytes, msgpOf(t, clone))
+	require.Equal(t, exampleBytes, msgpOf(t, example), "decoding into a clone modified the original")
+}
+
+func (k Kit) checkApply(t *testing.T, txab metatx.Transactable) {
+	if k.Fixture == nil {
+		return
+	}
+	app := k.Fixture.New(t)
+	// DeliverTx only applies valid transactables
+	if txab.Validate(app) != nil {
+		return
+	}
+	before := k.Fixture.Snapshot(t, app)
+	if txab.Apply(app) != nil {
+		require.Equal(t, before, k.Fixture.Snapshot(t, app), "failing Apply modified the state")
+	}
+}